@@ -0,0 +1,66 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"time"
+)
+
+// closedC is returned by After when backoff terminated, so receiving from it does not block.
+var closedC = func() chan time.Time {
+	c := make(chan time.Time)
+	close(c)
+	return c
+}()
+
+// After is a non-blocking variant of Wait for select loops. It increases the retry count the same way as Wait and returns
+// channel which receives the time after the backoff delay, e.g:
+//
+//	for b.Ongoing() {
+//		if err := do(); err == nil {
+//			break
+//		}
+//		select {
+//		case <-ctx.Done():
+//		case <-shutdown:
+//			b.Stop()
+//			return
+//		case <-b.After():
+//		}
+//	}
+//
+// Unlike Wait, the channel does not fire when context is done, so select should include ctx.Done(). If backoff terminated
+// the returned channel is closed. Backoff keeps only one timer: calling After again or Stop stops the previous one.
+func (b *Backoff) After() <-chan time.Time {
+	b.Stop()
+
+	sleepTime := b.NextDelay()
+	if !b.retryAllowed(sleepTime) {
+		return closedC
+	}
+	b.timer = b.clock.NewTimer(sleepTime)
+	return b.timer.C()
+}
+
+// C returns channel of the timer armed by the last After call, closed channel if backoff terminated, or nil if there is
+// no timer (e.g it was stopped). Receiving from nil channel blocks forever, so it can be used in select without checking
+// if backoff is waiting.
+func (b *Backoff) C() <-chan time.Time {
+	if b.timer == nil {
+		if b.Err() != nil {
+			return closedC
+		}
+		return nil
+	}
+	return b.timer.C()
+}
+
+// Stop stops the timer armed by After, if any, so it does not fire. It's safe to call Stop multiple times.
+func (b *Backoff) Stop() {
+	if b.timer == nil {
+		return
+	}
+	b.timer.Stop()
+	b.timer = nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestBackoff_After(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewFakeClock(start)
+	b := New(context.Background(), Config{Min: time.Second, Max: time.Second, MaxRetries: 3}, WithClock(c))
+	testutil.Assert(t, b.C() == nil)
+
+	ch := b.After()
+	testutil.Equals(t, 1, b.NumRetries())
+	testutil.Assert(t, ch == b.C())
+	select {
+	case <-ch:
+		t.Fatal("fired too early")
+	default:
+	}
+	c.Advance(time.Second)
+	testutil.Equals(t, start.Add(time.Second), <-b.C())
+
+	// New After call stops the previous timer.
+	prev := b.After()
+	next := b.After()
+	testutil.Equals(t, 3, b.NumRetries())
+	c.Advance(time.Second)
+	select {
+	case <-prev:
+		t.Fatal("stopped timer fired")
+	default:
+	}
+	// Retries are exhausted, so the last call returned closed channel.
+	_, ok := <-next
+	testutil.Assert(t, !ok)
+	_, ok = <-b.C()
+	testutil.Assert(t, !ok)
+	testutil.Assert(t, !b.Ongoing())
+	testutil.Equals(t, "terminated after 3 retries", b.Err().Error())
+
+	// Stop releases the timer.
+	b.Reset()
+	ch = b.After()
+	b.Stop()
+	b.Stop()
+	testutil.Assert(t, b.C() == nil)
+	c.Advance(time.Hour)
+	select {
+	case <-ch:
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
+
+func TestBackoff_AfterInSelect(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewFakeClock(start)
+	b := New(context.Background(), Config{Min: time.Second, Max: time.Second}, WithClock(c))
+	work := make(chan int, 1)
+
+	b.After()
+	c.Advance(500 * time.Millisecond)
+	work <- 1
+	select {
+	case <-work:
+	case <-b.C():
+		t.Fatal("fired too early")
+	}
+
+	// Other select case does not consume nor reset the pending timer.
+	c.Advance(500 * time.Millisecond)
+	select {
+	case <-work:
+		t.Fatal("unexpected work")
+	case now := <-b.C():
+		testutil.Equals(t, start.Add(time.Second), now)
+	default:
+		t.Fatal("timer did not fire")
+	}
+	testutil.Equals(t, 1, b.NumRetries())
+}
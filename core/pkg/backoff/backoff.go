@@ -7,48 +7,213 @@ package backoff
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
 	"time"
 )
 
 // Config configures a Backoff.
 type Config struct {
 	Min        time.Duration `yaml:"min_period"`  // Start backoff at this level
-	Max        time.Duration `yaml:"max_period"`  // Increase exponentially to this level
+	Max        time.Duration `yaml:"max_period"`  // Increase to this level
 	MaxRetries int           `yaml:"max_retries"` // Give up after this many; zero means infinite retries
+	// MaxElapsed makes backoff give up when this much time passed since it was created or reset, or when the next
+	// delay would exceed it. Zero means no limit.
+	MaxElapsed time.Duration `yaml:"max_elapsed"`
+	// AttemptTimeout limits duration of each attempt through context returned by Backoff.AttemptContext and passed
+	// by Retry. Zero means no limit.
+	AttemptTimeout time.Duration `yaml:"attempt_timeout"`
+	// Strategy selects how delays are computed, e.g "exponential" (default), "full_jitter", "equal_jitter",
+	// "decorrelated_jitter", "constant", "linear" or "fibonacci". See Strategy* constants for details.
+	Strategy string `yaml:"strategy"`
+	// Name identifies the backoff in metrics and events passed to Observer, e.g "s3_upload".
+	Name string `yaml:"name"`
 }
 
-// Backoff implements exponential backoff with randomized wait times.
+// Validate returns error if Config is invalid, e.g selects unknown strategy.
+func (cfg Config) Validate() error {
+	if cfg.Min < 0 || cfg.Max < 0 {
+		return errors.New("backoff min_period and max_period must not be negative")
+	}
+	if cfg.Max < cfg.Min {
+		return fmt.Errorf("backoff max_period %v must not be lower than min_period %v", cfg.Max, cfg.Min)
+	}
+	if cfg.MaxRetries < 0 {
+		return errors.New("backoff max_retries must not be negative")
+	}
+	if cfg.MaxElapsed < 0 || cfg.AttemptTimeout < 0 {
+		return errors.New("backoff max_elapsed and attempt_timeout must not be negative")
+	}
+	_, err := NewStrategy(cfg)
+	return err
+}
+
+var (
+	// ErrMaxRetries is the reason of TerminatedError returned when Config.MaxRetries was exceeded.
+	ErrMaxRetries = errors.New("max retries exceeded")
+	// ErrMaxElapsed is the reason of TerminatedError returned when Config.MaxElapsed was exceeded.
+	ErrMaxElapsed = errors.New("max elapsed time exceeded")
+)
+
+// TerminatedError is returned by Backoff.Err when backoff terminated because of Config limits. Use errors.Is with
+// ErrMaxRetries or ErrMaxElapsed to check which limit ended the backoff.
+type TerminatedError struct {
+	// Reason is ErrMaxRetries or ErrMaxElapsed.
+	Reason error
+	// Retries is the number of retries made.
+	Retries int
+	// MaxElapsed is the exceeded limit, if Reason is ErrMaxElapsed.
+	MaxElapsed time.Duration
+}
+
+func (e *TerminatedError) Error() string {
+	if e.Reason == ErrMaxElapsed {
+		return fmt.Sprintf("terminated after %d retries, max elapsed time %v exceeded", e.Retries, e.MaxElapsed)
+	}
+	return fmt.Sprintf("terminated after %d retries", e.Retries)
+}
+
+// Unwrap returns Reason.
+func (e *TerminatedError) Unwrap() error {
+	return e.Reason
+}
+
+// Option configures Backoff created with New.
+type Option func(*options)
+
+type options struct {
+	strategy   Strategy
+	clock      Clock
+	rnd        Rand
+	budget     Budget
+	breaker    *CircuitBreaker
+	observer   Observer
+	classifier Classifier
+	onRetry    func(retry int, delay time.Duration, err error)
+}
+
+func applyOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithStrategy sets Strategy used to compute delays, overriding the one selected by Config.Strategy.
+func WithStrategy(s Strategy) Option {
+	return func(o *options) {
+		o.strategy = s
+	}
+}
+
+// Backoff implements backoff with randomized wait times. Delays are computed by the Strategy (exponential by default).
 type Backoff struct {
-	cfg          Config
-	ctx          context.Context
-	numRetries   int
-	nextDelayMin time.Duration
-	nextDelayMax time.Duration
+	cfg        Config
+	ctx        context.Context
+	strategy   Strategy
+	clock      Clock
+	rnd        Rand
+	budget     Budget
+	breaker    *CircuitBreaker
+	observer   Observer
+	start      time.Time
+	numRetries int
+	lastDelay  time.Duration
+	// minNextDelay is the retry hint set by SetMinNextDelay, if any.
+	minNextDelay time.Duration
+
+	// allowed is the retry number for which the breaker allowed an attempt, or -1.
+	allowed int
+	// stopErr is the reason of termination by budget or breaker, if any.
+	stopErr error
+	// observed is true if termination was already reported to the observer.
+	observed bool
+	// timer is the timer armed by After, if any.
+	timer Timer
 }
 
 // New creates a Backoff object. Pass a Context that can also terminate the operation.
-func New(ctx context.Context, cfg Config) *Backoff {
-	return &Backoff{
-		cfg:          cfg,
-		ctx:          ctx,
-		nextDelayMin: cfg.Min,
-		nextDelayMax: doubleDuration(cfg.Min, cfg.Max),
+// Strategy is selected by cfg.Strategy. Unknown strategies fall back to the exponential one; use Config.Validate
+// to detect those when loading configuration.
+func New(ctx context.Context, cfg Config, opts ...Option) *Backoff {
+	o := applyOptions(opts)
+	if o.strategy == nil {
+		s, err := NewStrategy(cfg)
+		if err != nil {
+			s = Exponential(cfg.Min, cfg.Max)
+		}
+		o.strategy = s
+	}
+	if o.clock == nil {
+		o.clock = realClock{}
+	}
+	if o.rnd == nil {
+		o.rnd = globalRand{}
+	}
+
+	b := &Backoff{
+		cfg:      cfg,
+		ctx:      ctx,
+		strategy: o.strategy,
+		clock:    o.clock,
+		rnd:      o.rnd,
+		budget:   o.budget,
+		breaker:  o.breaker,
+		observer: o.observer,
+		start:    o.clock.Now(),
+		allowed:  -1,
+	}
+	if b.budget != nil {
+		b.budget.Request()
+	}
+	if b.breaker != nil {
+		b.breaker.clearFailures()
 	}
+	return b
 }
 
-// Reset the Backoff back to its initial condition.
+// Reset the Backoff back to its initial condition. It's not reported to the Budget as a new operation.
 func (b *Backoff) Reset() {
+	b.resetRetries()
+	b.start = b.clock.Now()
+	b.stopErr = nil
+	b.observed = false
+}
+
+// resetRetries resets the retry count and delays, but keeps the start time, so Config.MaxElapsed still limits
+// the whole operation.
+func (b *Backoff) resetRetries() {
+	b.Stop()
 	b.numRetries = 0
-	b.nextDelayMin = b.cfg.Min
-	b.nextDelayMax = doubleDuration(b.cfg.Min, b.cfg.Max)
+	b.lastDelay = 0
+	b.minNextDelay = 0
+	b.allowed = -1
 }
 
 // Ongoing returns true if caller should keep going.
 func (b *Backoff) Ongoing() bool {
-	// Stop if Context has errored or max retry count is exceeded.
-	return b.ctx.Err() == nil && (b.cfg.MaxRetries == 0 || b.numRetries < b.cfg.MaxRetries)
+	if b.ongoing() {
+		return true
+	}
+	b.observeTermination()
+	return false
+}
+
+func (b *Backoff) ongoing() bool {
+	// Stop if Context has errored or max retry count or elapsed time is exceeded.
+	if b.ctx.Err() != nil || b.stopErr != nil || b.maxRetriesExceeded() || b.maxElapsedExceeded(0) {
+		return false
+	}
+	// Ask circuit breaker once per attempt, as it may allow only one probe attempt.
+	if b.breaker != nil && b.allowed != b.numRetries {
+		if !b.breaker.Allow() {
+			b.stopErr = ErrCircuitOpen
+			return false
+		}
+		b.allowed = b.numRetries
+	}
+	return true
 }
 
 // Err returns the reason for terminating the backoff, or nil if it didn't terminate.
@@ -56,57 +221,107 @@ func (b *Backoff) Err() error {
 	if b.ctx.Err() != nil {
 		return b.ctx.Err()
 	}
-	if b.cfg.MaxRetries != 0 && b.numRetries >= b.cfg.MaxRetries {
-		return fmt.Errorf("terminated after %d retries", b.numRetries)
+	if b.stopErr != nil {
+		return b.stopErr
+	}
+	if b.maxRetriesExceeded() {
+		return &TerminatedError{Reason: ErrMaxRetries, Retries: b.numRetries}
+	}
+	if b.maxElapsedExceeded(0) {
+		return &TerminatedError{Reason: ErrMaxElapsed, Retries: b.numRetries, MaxElapsed: b.cfg.MaxElapsed}
 	}
 	return nil
 }
 
+func (b *Backoff) maxRetriesExceeded() bool {
+	return b.cfg.MaxRetries != 0 && b.numRetries >= b.cfg.MaxRetries
+}
+
+// maxElapsedExceeded returns true if time elapsed since start plus given delay is not lower than max elapsed time.
+func (b *Backoff) maxElapsedExceeded(delay time.Duration) bool {
+	return b.cfg.MaxElapsed != 0 && b.clock.Now().Sub(b.start)+delay >= b.cfg.MaxElapsed
+}
+
+// AttemptContext returns context for the next attempt, derived from the Backoff context with Config.AttemptTimeout
+// applied, if set. Cancel function must be called when the attempt finishes.
+// NOTE: Attempt timeout is measured with wall clock, even if other Clock was set with WithClock.
+func (b *Backoff) AttemptContext() (context.Context, context.CancelFunc) {
+	if b.cfg.AttemptTimeout > 0 {
+		return context.WithTimeout(b.ctx, b.cfg.AttemptTimeout)
+	}
+	return context.WithCancel(b.ctx)
+}
+
 // NumRetries returns the number of retries so far.
 func (b *Backoff) NumRetries() int {
 	return b.numRetries
 }
 
 // Wait sleeps for the backoff time then increases the retry count and backoff time.
-// Returns immediately if Context is terminated.
+// Returns immediately if Context is terminated or the backoff terminated. Wait reports failed attempt to
+// the circuit breaker and consumes retry budget, if those are set.
 func (b *Backoff) Wait() {
 	// Increase the number of retries and get the next delay.
 	sleepTime := b.NextDelay()
-
-	if b.Ongoing() {
-		select {
-		case <-b.ctx.Done():
-		case <-time.After(sleepTime):
-		}
+	if b.retryAllowed(sleepTime) {
+		b.sleep(sleepTime)
 	}
 }
 
-func (b *Backoff) NextDelay() time.Duration {
-	b.numRetries++
+// Success reports successful attempt to the circuit breaker, if set. Retry calls it; callers using Backoff directly
+// should call it when an attempt succeeds, as Wait reports failures only.
+func (b *Backoff) Success() {
+	if b.breaker != nil {
+		b.breaker.Success()
+	}
+}
 
-	// Handle the edge case the min and max have the same value
-	// (or due to some misconfig max is < min).
-	if b.nextDelayMin >= b.nextDelayMax {
-		return b.nextDelayMin
+// retryAllowed reports failed attempt to the circuit breaker and returns true if the backoff should wait for the next
+// attempt, consuming the retry budget. Retry with the given delay is reported to the observer.
+func (b *Backoff) retryAllowed(delay time.Duration) bool {
+	if b.breaker != nil {
+		b.breaker.Failure()
+	}
+	if !b.Ongoing() {
+		return false
 	}
+	if b.maxElapsedExceeded(delay) {
+		// There is no point in waiting, if the next attempt would be made after max elapsed time anyway.
+		b.stopErr = &TerminatedError{Reason: ErrMaxElapsed, Retries: b.numRetries, MaxElapsed: b.cfg.MaxElapsed}
+		b.observeTermination()
+		return false
+	}
+	if b.budget != nil && !b.budget.AllowRetry() {
+		b.stopErr = ErrRetryBudgetExhausted
+		b.observeTermination()
+		return false
+	}
+	if b.observer != nil {
+		b.observer.OnRetry(b.cfg.Name, b.numRetries, delay)
+	}
+	return true
+}
 
-	// Add a jitter within the next exponential backoff range.
-	sleepTime := b.nextDelayMin + time.Duration(rand.Int63n(int64(b.nextDelayMax-b.nextDelayMin)))
+func (b *Backoff) sleep(sleepTime time.Duration) {
+	t := b.clock.NewTimer(sleepTime)
+	defer t.Stop()
 
-	// Apply the exponential backoff to calculate the next jitter
-	// range, unless we've already reached the max.
-	if b.nextDelayMax < b.cfg.Max {
-		b.nextDelayMin = doubleDuration(b.nextDelayMin, b.cfg.Max)
-		b.nextDelayMax = doubleDuration(b.nextDelayMax, b.cfg.Max)
+	select {
+	case <-b.ctx.Done():
+	case <-t.C():
 	}
-
-	return sleepTime
 }
 
-func doubleDuration(value time.Duration, max time.Duration) time.Duration {
-	value = value * 2
-	if value <= max {
-		return value
+// NextDelay increases the retry count and returns the delay before next retry computed by the Strategy,
+// but not shorter than the hint set by SetMinNextDelay.
+func (b *Backoff) NextDelay() time.Duration {
+	b.numRetries++
+	b.lastDelay = b.strategy.NextDelay(b.numRetries, b.lastDelay, b.rnd)
+
+	delay := b.lastDelay
+	if delay < b.minNextDelay {
+		delay = b.minNextDelay
 	}
-	return max
+	b.minNextDelay = 0
+	return delay
 }
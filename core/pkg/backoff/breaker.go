@@ -0,0 +1,132 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Backoff.Err if Backoff terminated, because its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is a state of CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed allows all attempts.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen denies all attempts until cooldown passes.
+	CircuitOpen
+	// CircuitHalfOpen allows single probe attempt, which result decides if circuit is closed or open again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "CircuitState(" + strconv.Itoa(int(s)) + ")"
+}
+
+// WithCircuitBreaker sets CircuitBreaker shared with other Backoff instances. Backoff asks breaker before each attempt
+// (in Ongoing) and reports each failed attempt (in Wait). If breaker does not allow an attempt, Backoff terminates with
+// ErrCircuitOpen. Successful attempts are reported by Retry; callers using Backoff directly should call
+// Backoff.Success. As callers may not do so, failure streak is cleared when a new Backoff is created, so the circuit
+// opens only when threshold attempts of a single operation failed in a row.
+func WithCircuitBreaker(c *CircuitBreaker) Option {
+	return func(o *options) {
+		o.breaker = c
+	}
+}
+
+// CircuitBreaker stops attempts to a failing dependency. It opens after given number of consecutive failures and denies
+// all attempts for a cooldown period. Then it allows a single probe attempt (half-open state): success closes the
+// circuit, failure opens it again. It's safe for concurrent use.
+type CircuitBreaker struct {
+	clock     Clock
+	threshold int
+	cooldown  time.Duration
+
+	mtx      sync.Mutex
+	state    CircuitState
+	failures int
+	since    time.Time
+}
+
+// NewCircuitBreaker returns closed CircuitBreaker, which opens after threshold consecutive failures for cooldown.
+// Clock is used to measure cooldown; nil means wall clock.
+func NewCircuitBreaker(threshold int, cooldown time.Duration, clock Clock) *CircuitBreaker {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &CircuitBreaker{clock: clock, threshold: threshold, cooldown: cooldown}
+}
+
+// State returns the current state of the breaker.
+func (c *CircuitBreaker) State() CircuitState {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.state
+}
+
+// Allow returns true if an attempt can be made now. In half-open state only one probe is allowed; if its result is
+// not reported within cooldown, another probe is allowed.
+func (c *CircuitBreaker) Allow() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.state == CircuitClosed {
+		return true
+	}
+	if now := c.clock.Now(); now.Sub(c.since) >= c.cooldown {
+		c.state = CircuitHalfOpen
+		c.since = now
+		return true
+	}
+	return false
+}
+
+// clearFailures forgets failures reported so far, unless the circuit is already open. It's called when a new operation
+// starts, as its success may not be reported.
+func (c *CircuitBreaker) clearFailures() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.state == CircuitClosed {
+		c.failures = 0
+	}
+}
+
+// Success reports successful attempt. It closes the circuit.
+func (c *CircuitBreaker) Success() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.state = CircuitClosed
+	c.failures = 0
+}
+
+// Failure reports failed attempt. It opens the circuit if the probe failed or there were threshold consecutive failures.
+func (c *CircuitBreaker) Failure() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.failures++
+	if c.state == CircuitOpen {
+		return
+	}
+	if c.state == CircuitHalfOpen || c.failures >= c.threshold {
+		c.state = CircuitOpen
+		c.since = c.clock.Now()
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned by Backoff.Err if Backoff terminated, because shared retry budget was exhausted.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// Budget limits retries made by many Backoff instances sharing it, so they do not overload a dependency that is
+// already failing. Implementations must be safe for concurrent use.
+type Budget interface {
+	// Request records a new operation, which may be retried. Backoff calls it once, when it's created.
+	Request()
+	// AllowRetry returns true and consumes the budget if retry can be made now. Backoff calls it before each wait.
+	AllowRetry() bool
+}
+
+// WithBudget sets Budget shared with other Backoff instances. If budget does not allow a retry, Backoff terminates
+// with ErrRetryBudgetExhausted.
+func WithBudget(b Budget) Option {
+	return func(o *options) {
+		o.budget = b
+	}
+}
+
+// TokenBucket is a Budget allowing on average given number of retries per second, with bursts up to a given number of retries.
+type TokenBucket struct {
+	clock     Clock
+	perSecond float64
+	burst     float64
+
+	mtx    sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns TokenBucket allowing perSecond retries on average and at most burst retries at once.
+// Bucket starts full. Clock is used to refill tokens; nil means wall clock.
+func NewTokenBucket(perSecond float64, burst int, clock Clock) *TokenBucket {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &TokenBucket{
+		clock:     clock,
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      clock.Now(),
+	}
+}
+
+// Request does nothing, as TokenBucket limits retries only by time.
+func (t *TokenBucket) Request() {}
+
+// AllowRetry returns true and takes one token from the bucket if there is any.
+func (t *TokenBucket) AllowRetry() bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	now := t.clock.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.perSecond
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// RatioBudget is a Budget allowing retries up to given ratio of operations, e.g 0.1 means there can be
+// one retry per ten operations on average.
+type RatioBudget struct {
+	ratio float64
+	burst float64
+
+	mtx    sync.Mutex
+	tokens float64
+}
+
+// NewRatioBudget returns RatioBudget allowing ratio retries per operation. Up to burst retries can be saved for later,
+// and the budget starts with burst retries allowed, so operations can be retried right after start.
+func NewRatioBudget(ratio float64, burst int) *RatioBudget {
+	return &RatioBudget{ratio: ratio, burst: float64(burst), tokens: float64(burst)}
+}
+
+// Request adds ratio of a retry to the budget.
+func (r *RatioBudget) Request() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.tokens += r.ratio
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+}
+
+// AllowRetry returns true and consumes one retry from the budget if there is any.
+func (r *RatioBudget) AllowRetry() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
@@ -0,0 +1,219 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestTokenBucket(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	b := NewTokenBucket(2, 3, c)
+
+	for i := 0; i < 3; i++ {
+		testutil.Assert(t, b.AllowRetry())
+	}
+	testutil.Assert(t, !b.AllowRetry())
+
+	c.Advance(500 * time.Millisecond)
+	testutil.Assert(t, b.AllowRetry())
+	testutil.Assert(t, !b.AllowRetry())
+
+	// Bucket does not fill above burst.
+	c.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		testutil.Assert(t, b.AllowRetry())
+	}
+	testutil.Assert(t, !b.AllowRetry())
+}
+
+func TestRatioBudget(t *testing.T) {
+	b := NewRatioBudget(0.5, 2)
+
+	testutil.Assert(t, b.AllowRetry())
+	testutil.Assert(t, b.AllowRetry())
+	testutil.Assert(t, !b.AllowRetry())
+
+	b.Request()
+	testutil.Assert(t, !b.AllowRetry())
+	b.Request()
+	testutil.Assert(t, b.AllowRetry())
+}
+
+func TestBackoff_WithBudget(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	budget := NewRatioBudget(0.5, 2)
+	cfg := Config{Min: time.Second, Max: time.Second}
+
+	// Budget is shared, so the first backoff takes all retries.
+	b1 := New(context.Background(), cfg, WithClock(c), WithBudget(budget))
+	b1.Wait()
+	b1.Wait()
+	testutil.Assert(t, b1.Ongoing())
+	testutil.Ok(t, b1.Err())
+	b1.Wait()
+	testutil.Assert(t, !b1.Ongoing())
+	testutil.Equals(t, ErrRetryBudgetExhausted, b1.Err())
+	testutil.Equals(t, 2*time.Second, c.Now().Sub(time.Unix(0, 0)))
+
+	// Two new operations bring one retry back.
+	New(context.Background(), cfg, WithClock(c), WithBudget(budget))
+	b2 := New(context.Background(), cfg, WithClock(c), WithBudget(budget))
+	b2.Wait()
+	testutil.Assert(t, b2.Ongoing())
+	b2.Wait()
+	testutil.Equals(t, ErrRetryBudgetExhausted, b2.Err())
+}
+
+func TestBackoff_WithBudgetReset(t *testing.T) {
+	budget := NewRatioBudget(0.5, 2)
+	testutil.Assert(t, budget.AllowRetry())
+	testutil.Assert(t, budget.AllowRetry())
+
+	// Reset does not count as a new operation.
+	b := New(context.Background(), Config{}, WithBudget(budget))
+	b.Reset()
+	testutil.Assert(t, !budget.AllowRetry())
+
+	New(context.Background(), Config{}, WithBudget(budget))
+	testutil.Assert(t, budget.AllowRetry())
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	cb := NewCircuitBreaker(2, time.Minute, c)
+
+	testutil.Equals(t, CircuitClosed, cb.State())
+	cb.Failure()
+	testutil.Assert(t, cb.Allow())
+	cb.Success()
+	cb.Failure()
+	testutil.Equals(t, CircuitClosed, cb.State())
+	cb.Failure()
+	testutil.Equals(t, CircuitOpen, cb.State())
+	testutil.Assert(t, !cb.Allow())
+
+	// Single probe is allowed after cooldown.
+	c.Advance(time.Minute)
+	testutil.Assert(t, cb.Allow())
+	testutil.Equals(t, CircuitHalfOpen, cb.State())
+	testutil.Assert(t, !cb.Allow())
+
+	// Failed probe opens circuit again.
+	cb.Failure()
+	testutil.Equals(t, CircuitOpen, cb.State())
+	testutil.Assert(t, !cb.Allow())
+
+	// Successful probe closes it.
+	c.Advance(time.Minute)
+	testutil.Assert(t, cb.Allow())
+	cb.Success()
+	testutil.Equals(t, CircuitClosed, cb.State())
+	testutil.Assert(t, cb.Allow())
+	testutil.Equals(t, "half-open", CircuitHalfOpen.String())
+}
+
+func TestRetry_WithCircuitBreaker(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	cb := NewCircuitBreaker(3, time.Hour, c)
+	cfg := Config{Min: time.Second, Max: time.Second, MaxRetries: 10}
+
+	attempts := 0
+	fail := func(context.Context) error {
+		attempts++
+		return errors.New("unavailable")
+	}
+
+	err := Retry(context.Background(), cfg, fail, WithClock(c), WithCircuitBreaker(cb))
+	testutil.Equals(t, "2 errors: unavailable; circuit breaker is open", err.Error())
+	testutil.Assert(t, errors.Is(err, ErrCircuitOpen))
+	testutil.Equals(t, 3, attempts)
+
+	// Other operations are not attempted at all while circuit is open.
+	err = Retry(context.Background(), cfg, fail, WithClock(c), WithCircuitBreaker(cb))
+	testutil.Equals(t, "circuit breaker is open", err.Error())
+	testutil.Equals(t, 3, attempts)
+
+	// After cooldown successful probe closes the circuit.
+	c.Advance(time.Hour)
+	testutil.Ok(t, Retry(context.Background(), cfg, func(context.Context) error { return nil }, WithClock(c), WithCircuitBreaker(cb)))
+	testutil.Equals(t, CircuitClosed, cb.State())
+}
+
+func TestBackoff_WithCircuitBreaker(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	cb := NewCircuitBreaker(2, time.Hour, c)
+	cfg := Config{Min: time.Second, Max: time.Second, MaxRetries: 10}
+
+	// Operations failing once and then succeeding never make two consecutive failures.
+	for i := 0; i < 3; i++ {
+		b := New(context.Background(), cfg, WithClock(c), WithCircuitBreaker(cb))
+		for attempt := 1; b.Ongoing(); attempt++ {
+			if attempt == 2 {
+				b.Success()
+				break
+			}
+			b.Wait()
+		}
+		testutil.Ok(t, b.Err())
+		testutil.Equals(t, CircuitClosed, cb.State())
+	}
+
+	b := New(context.Background(), cfg, WithClock(c), WithCircuitBreaker(cb))
+	for b.Ongoing() {
+		b.Wait()
+	}
+	testutil.Equals(t, ErrCircuitOpen, b.Err())
+	testutil.Equals(t, 2, b.NumRetries())
+	testutil.Equals(t, CircuitOpen, cb.State())
+
+	// After cooldown successful probe closes the circuit.
+	c.Advance(time.Hour)
+	b = New(context.Background(), cfg, WithClock(c), WithCircuitBreaker(cb))
+	testutil.Assert(t, b.Ongoing())
+	testutil.Equals(t, CircuitHalfOpen, cb.State())
+	b.Success()
+	testutil.Equals(t, CircuitClosed, cb.State())
+}
+
+func TestBackoff_WithCircuitBreakerWithoutSuccess(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	cb := NewCircuitBreaker(3, time.Hour, c)
+	cfg := Config{Min: time.Second, Max: time.Second, MaxRetries: 10}
+
+	// Callers not reporting success do not trip the breaker with failures scattered over healthy operations.
+	for i := 0; i < 5; i++ {
+		b := New(context.Background(), cfg, WithClock(c), WithCircuitBreaker(cb))
+		for attempt := 1; b.Ongoing(); attempt++ {
+			if attempt == 3 {
+				break
+			}
+			b.Wait()
+		}
+		testutil.Ok(t, b.Err())
+		testutil.Equals(t, CircuitClosed, cb.State())
+	}
+
+	// Operation failing threshold times in a row opens the circuit.
+	b := New(context.Background(), cfg, WithClock(c), WithCircuitBreaker(cb))
+	for b.Ongoing() {
+		b.Wait()
+	}
+	testutil.Equals(t, ErrCircuitOpen, b.Err())
+	testutil.Equals(t, 3, b.NumRetries())
+
+	// Open circuit is kept for new operations.
+	b = New(context.Background(), cfg, WithClock(c), WithCircuitBreaker(cb))
+	testutil.Assert(t, !b.Ongoing())
+	testutil.Equals(t, ErrCircuitOpen, b.Err())
+}
@@ -0,0 +1,174 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and creates timers. It allows replacing the wall clock in tests, see FakeClock.
+type Clock interface {
+	Now() time.Time
+	// NewTimer returns Timer that sends the current time on its channel after at least duration d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a single event timer, like time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered.
+	C() <-chan time.Time
+	// Stop prevents the Timer from firing. It returns false if the timer has already fired or been stopped.
+	Stop() bool
+}
+
+// WithClock sets Clock used by Backoff. Wall clock is used by default.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithRand sets source of random numbers used by the Strategy. Global math/rand source is used by default.
+func WithRand(r Rand) Option {
+	return func(o *options) {
+		o.rnd = r
+	}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer { return realTimer{t: time.NewTimer(d)} }
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+
+func (t realTimer) Stop() bool { return t.t.Stop() }
+
+// FakeClock is a Clock which time moves only when Advance is called (or with each new timer, if auto advance is enabled).
+// It's safe for concurrent use. The zero value is a clock set to the zero time.
+type FakeClock struct {
+	mtx         sync.Mutex
+	cond        *sync.Cond
+	now         time.Time
+	timers      []*fakeTimer
+	autoAdvance bool
+}
+
+// NewFakeClock returns FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mtx)
+	return c
+}
+
+// Now returns the current time of the fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.now
+}
+
+// NewTimer returns Timer that fires when the fake clock is advanced by at least d. Timer with non-positive d fires
+// immediately.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1), deadline: c.now.Add(d)}
+	if !t.deadline.After(c.now) {
+		t.ch <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.condLocked().Broadcast()
+	if c.autoAdvance {
+		c.advance(d)
+	}
+	return t
+}
+
+// SetAutoAdvance enables or disables auto advance. When enabled, each new timer advances the clock to its deadline,
+// so it fires immediately. It allows running code waiting on Backoff (e.g Retry) in tests instantly, without
+// advancing the clock from another goroutine.
+func (c *FakeClock) SetAutoAdvance(enabled bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.autoAdvance = enabled
+}
+
+// Advance moves the clock forward by d, firing all timers with deadline not later than the new time, in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.advance(d)
+}
+
+func (c *FakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].deadline.Before(c.timers[j].deadline) })
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	for i := len(pending); i < len(c.timers); i++ {
+		c.timers[i] = nil
+	}
+	c.timers = pending
+}
+
+// BlockUntil blocks until there are at least n timers waiting to fire. It allows advancing the clock only after
+// the code under test started waiting.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for len(c.timers) < n {
+		c.condLocked().Wait()
+	}
+}
+
+// condLocked returns condition variable signaled when new timer is added, creating it if needed, so the zero value
+// of FakeClock is usable. It must be called with c.mtx held.
+func (c *FakeClock) condLocked() *sync.Cond {
+	if c.cond == nil {
+		c.cond = sync.NewCond(&c.mtx)
+	}
+	return c.cond
+}
+
+type fakeTimer struct {
+	c        *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mtx.Lock()
+	defer t.c.mtx.Unlock()
+
+	for i, pt := range t.c.timers {
+		if pt == t {
+			t.c.timers = append(t.c.timers[:i], t.c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewFakeClock(start)
+
+	t1 := c.NewTimer(2 * time.Second)
+	t2 := c.NewTimer(1 * time.Second)
+	t3 := c.NewTimer(3 * time.Second)
+	testutil.Assert(t, t3.Stop())
+	testutil.Assert(t, !t3.Stop())
+
+	c.Advance(500 * time.Millisecond)
+	select {
+	case <-t1.C():
+		t.Fatal("timer fired too early")
+	case <-t2.C():
+		t.Fatal("timer fired too early")
+	default:
+	}
+
+	c.Advance(5 * time.Second)
+	testutil.Equals(t, start.Add(5500*time.Millisecond), <-t1.C())
+	testutil.Equals(t, start.Add(5500*time.Millisecond), <-t2.C())
+	testutil.Assert(t, !t1.Stop())
+	testutil.Equals(t, start.Add(5500*time.Millisecond), c.Now())
+
+	c.Advance(time.Hour)
+	select {
+	case <-t3.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+}
+
+func TestFakeClock_NonPositiveDuration(t *testing.T) {
+	var c FakeClock
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		tm := c.NewTimer(d)
+		select {
+		case now := <-tm.C():
+			testutil.Equals(t, time.Time{}, now)
+		default:
+			t.Fatalf("timer with duration %v did not fire immediately", d)
+		}
+		testutil.Assert(t, !tm.Stop())
+	}
+
+	// Backoff with zero delay does not block on fake clock.
+	b := New(context.Background(), Config{MaxRetries: 2}, WithClock(&c))
+	b.Wait()
+	testutil.Equals(t, 1, b.NumRetries())
+}
+
+func TestBackoff_WaitWithFakeClock(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	b := New(context.Background(), Config{Min: time.Second, Max: time.Second}, WithClock(c))
+
+	done := make(chan struct{})
+	go func() {
+		b.Wait()
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("wait returned too early")
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.Advance(time.Millisecond)
+	<-done
+	testutil.Equals(t, 1, b.NumRetries())
+}
+
+func TestRetry_Deterministic(t *testing.T) {
+	retry := func() (delays []time.Duration, elapsed time.Duration) {
+		c := NewFakeClock(time.Unix(0, 0))
+		c.SetAutoAdvance(true)
+
+		err := Retry(context.Background(), Config{Min: time.Second, Max: time.Minute, MaxRetries: 10, Strategy: StrategyFullJitter},
+			func(context.Context) error { return errors.New("fail") },
+			WithClock(c),
+			WithRand(rand.New(rand.NewSource(1))),
+			WithOnRetry(func(_ int, delay time.Duration, _ error) { delays = append(delays, delay) }),
+		)
+		testutil.Equals(t, "2 errors: fail; terminated after 10 retries", err.Error())
+		return delays, c.Now().Sub(time.Unix(0, 0))
+	}
+
+	delays, elapsed := retry()
+	testutil.Equals(t, 9, len(delays))
+
+	var sum time.Duration
+	for _, d := range delays {
+		sum += d
+	}
+	testutil.Equals(t, sum, elapsed)
+
+	// Same seed gives the same delays.
+	delays2, _ := retry()
+	testutil.Equals(t, delays, delays2)
+}
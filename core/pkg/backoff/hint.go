@@ -0,0 +1,73 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetMinNextDelay sets minimal delay before the next retry, e.g told by the dependency with HTTP Retry-After header or
+// gRPC RetryInfo. Hint is clamped by Config.Max (if set) and applies only to the next NextDelay or Wait.
+func (b *Backoff) SetMinNextDelay(d time.Duration) {
+	if b.cfg.Max > 0 && d > b.cfg.Max {
+		d = b.cfg.Max
+	}
+	b.minNextDelay = d
+}
+
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e retryAfterError) Error() string { return e.err.Error() }
+
+func (e retryAfterError) Unwrap() error { return e.err }
+
+func (e retryAfterError) RetryAfter() time.Duration { return e.after }
+
+// RetryAfterError returns err carrying a hint that it should not be retried earlier than after given duration.
+// Hint can be read with RetryAfter. It returns nil if err is nil.
+func RetryAfterError(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return retryAfterError{err: err, after: after}
+}
+
+// RetryAfter returns retry hint of the first error in the err chain implementing RetryAfter() time.Duration method
+// (e.g created by RetryAfterError). Retry uses it to delay the next attempt. It returns false if there is no hint.
+func RetryAfter(err error) (time.Duration, bool) {
+	var hint interface{ RetryAfter() time.Duration }
+	if !errors.As(err, &hint) {
+		return 0, false
+	}
+	return hint.RetryAfter(), true
+}
+
+// ParseRetryAfter parses value of HTTP Retry-After header, which is either number of seconds or HTTP date. Dates
+// are converted to duration relative to now, dates in the past are returned as zero duration. It returns false if
+// value can't be parsed.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
@@ -0,0 +1,100 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestBackoff_SetMinNextDelay(t *testing.T) {
+	b := New(context.Background(), Config{Min: time.Second, Max: time.Minute, Strategy: StrategyConstant})
+
+	b.SetMinNextDelay(10 * time.Second)
+	testutil.Equals(t, 10*time.Second, b.NextDelay())
+	// Hint applies only to the next delay.
+	testutil.Equals(t, time.Second, b.NextDelay())
+
+	// Hint shorter than computed delay is ignored.
+	b.SetMinNextDelay(time.Millisecond)
+	testutil.Equals(t, time.Second, b.NextDelay())
+
+	// Hint is clamped by max.
+	b.SetMinNextDelay(time.Hour)
+	testutil.Equals(t, time.Minute, b.NextDelay())
+}
+
+func TestRetryAfter(t *testing.T) {
+	_, ok := RetryAfter(errors.New("plain"))
+	testutil.Assert(t, !ok)
+	_, ok = RetryAfter(nil)
+	testutil.Assert(t, !ok)
+	testutil.Ok(t, RetryAfterError(nil, time.Second))
+
+	errLimited := errors.New("rate limited")
+	err := fmt.Errorf("upload: %w", RetryAfterError(errLimited, 5*time.Second))
+	testutil.Equals(t, "upload: rate limited", err.Error())
+	testutil.Assert(t, errors.Is(err, errLimited))
+
+	d, ok := RetryAfter(err)
+	testutil.Assert(t, ok)
+	testutil.Equals(t, 5*time.Second, d)
+
+	d, ok = RetryAfter(merrors.New(errors.New("other"), err).Err())
+	testutil.Assert(t, ok)
+	testutil.Equals(t, 5*time.Second, d)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+
+	for _, tcase := range []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "120", expected: 2 * time.Minute, ok: true},
+		{value: " 0 ", expected: 0, ok: true},
+		{value: "Wed, 21 Oct 2015 07:29:00 GMT", expected: time.Minute, ok: true},
+		{value: "Wed, 21 Oct 2015 07:27:00 GMT", expected: 0, ok: true},
+		{value: "-1"},
+		{value: "soon"},
+		{value: ""},
+	} {
+		t.Run(tcase.value, func(t *testing.T) {
+			d, ok := ParseRetryAfter(tcase.value, now)
+			testutil.Equals(t, tcase.ok, ok)
+			testutil.Equals(t, tcase.expected, d)
+		})
+	}
+}
+
+func TestRetry_RetryAfterHint(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+
+	var delays []time.Duration
+	attempts := 0
+	err := Retry(context.Background(), Config{Min: time.Second, Max: time.Minute, Strategy: StrategyConstant}, func(context.Context) error {
+		attempts++
+		switch attempts {
+		case 1:
+			return RetryAfterError(errors.New("rate limited"), 30*time.Second)
+		case 2:
+			return RetryAfterError(errors.New("rate limited"), time.Hour)
+		case 3:
+			return errors.New("unavailable")
+		}
+		return nil
+	}, WithClock(c), WithOnRetry(func(_ int, delay time.Duration, _ error) { delays = append(delays, delay) }))
+	testutil.Ok(t, err)
+	testutil.Equals(t, []time.Duration{30 * time.Second, time.Minute, time.Second}, delays)
+	testutil.Equals(t, 91*time.Second, c.Now().Sub(time.Unix(0, 0)))
+}
@@ -0,0 +1,103 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestBackoff_MaxRetriesError(t *testing.T) {
+	b := New(context.Background(), Config{MaxRetries: 2})
+	b.NextDelay()
+	b.NextDelay()
+
+	testutil.Assert(t, !b.Ongoing())
+	err := b.Err()
+	testutil.Equals(t, "terminated after 2 retries", err.Error())
+	testutil.Assert(t, errors.Is(err, ErrMaxRetries))
+	testutil.Assert(t, !errors.Is(err, ErrMaxElapsed))
+
+	var terr *TerminatedError
+	testutil.Assert(t, errors.As(err, &terr))
+	testutil.Equals(t, 2, terr.Retries)
+}
+
+func TestBackoff_MaxElapsed(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	cfg := Config{Min: 10 * time.Second, Max: 10 * time.Second, MaxElapsed: 30 * time.Second}
+
+	b := New(context.Background(), cfg, WithClock(c))
+	attempts := 0
+	for b.Ongoing() {
+		attempts++
+		// Each attempt takes 2s.
+		c.Advance(2 * time.Second)
+		b.Wait()
+	}
+	// Attempts at 0s, 12s and 24s. Next one would be made at 36s, so backoff gives up without waiting.
+	testutil.Equals(t, 3, attempts)
+	testutil.Equals(t, 26*time.Second, c.Now().Sub(time.Unix(0, 0)))
+	err := b.Err()
+	testutil.Equals(t, "terminated after 3 retries, max elapsed time 30s exceeded", err.Error())
+	testutil.Assert(t, errors.Is(err, ErrMaxElapsed))
+
+	// Elapsed time is counted from the reset.
+	b.Reset()
+	testutil.Assert(t, b.Ongoing())
+	testutil.Ok(t, b.Err())
+
+	// Elapsed time is checked even without Wait.
+	c.Advance(30 * time.Second)
+	testutil.Assert(t, !b.Ongoing())
+	testutil.Assert(t, errors.Is(b.Err(), ErrMaxElapsed))
+
+	testutil.NotOk(t, Config{MaxElapsed: -time.Second}.Validate())
+}
+
+func TestRetry_MaxElapsedWithResetBackoff(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	cfg := Config{Min: time.Second, Max: time.Second, MaxElapsed: 5 * time.Second}
+
+	attempts := 0
+	err := Retry(context.Background(), cfg,
+		func(context.Context) error {
+			attempts++
+			return errors.New("progress")
+		},
+		WithClock(c),
+		WithClassifier(func(error) Class { return ResetBackoff }),
+	)
+	// Reset of retries does not restart elapsed time.
+	testutil.Assert(t, errors.Is(err, ErrMaxElapsed))
+	testutil.Equals(t, 5, attempts)
+	testutil.Equals(t, 4*time.Second, c.Now().Sub(time.Unix(0, 0)))
+}
+
+func TestRetry_AttemptTimeout(t *testing.T) {
+	var deadlines []bool
+	err := Retry(context.Background(), Config{Min: time.Millisecond, Max: time.Millisecond, MaxRetries: 2, AttemptTimeout: time.Millisecond},
+		func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			deadlines = append(deadlines, ok)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	testutil.Equals(t, "2 errors: context deadline exceeded; terminated after 2 retries", err.Error())
+	testutil.Assert(t, errors.Is(err, ErrMaxRetries))
+	testutil.Equals(t, []bool{true, true}, deadlines)
+
+	b := New(context.Background(), Config{})
+	ctx, cancel := b.AttemptContext()
+	_, ok := ctx.Deadline()
+	testutil.Assert(t, !ok)
+	cancel()
+	testutil.NotOk(t, ctx.Err())
+}
@@ -0,0 +1,187 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDelayBuckets are upper bounds of delay histogram buckets used by NewMetrics if none are given.
+var DefaultDelayBuckets = []time.Duration{
+	10 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second, time.Minute, 5 * time.Minute,
+}
+
+// Stats are metrics of backoff instances with the same name.
+type Stats struct {
+	// Retries is the number of retries.
+	Retries int
+	// ContextDone is the number of backoff instances terminated by their context.
+	ContextDone int
+	// GaveUp is the number of backoff instances terminated for other reasons, e.g max retries.
+	GaveUp int
+	// DelaySum is the sum of delays before retries.
+	DelaySum time.Duration
+	// DelayBuckets are cumulative counts of delays not longer than each of Metrics buckets.
+	DelayBuckets []int
+}
+
+// Metrics is an Observer collecting in-memory counters and delay histograms for each backoff name (Config.Name).
+// Metrics can be read with Stats or exposed in Prometheus text format with WriteTo and ServeHTTP, without depending on
+// Prometheus client. It's safe for concurrent use, so it can be shared by all backoff instances.
+type Metrics struct {
+	buckets []time.Duration
+
+	mtx   sync.Mutex
+	stats map[string]*Stats
+}
+
+// NewMetrics returns Metrics with delay histogram using given bucket upper bounds (DefaultDelayBuckets if none).
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultDelayBuckets
+	}
+	b := append([]time.Duration(nil), buckets...)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return &Metrics{buckets: b, stats: map[string]*Stats{}}
+}
+
+func (m *Metrics) get(name string) *Stats {
+	s, ok := m.stats[name]
+	if !ok {
+		s = &Stats{DelayBuckets: make([]int, len(m.buckets))}
+		m.stats[name] = s
+	}
+	return s
+}
+
+// OnRetry counts retry and its delay.
+func (m *Metrics) OnRetry(name string, _ int, delay time.Duration) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	s := m.get(name)
+	s.Retries++
+	s.DelaySum += delay
+	for i, le := range m.buckets {
+		if delay <= le {
+			s.DelayBuckets[i]++
+		}
+	}
+}
+
+// OnContextDone counts backoff terminated by context.
+func (m *Metrics) OnContextDone(name string, _ int, _ error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.get(name).ContextDone++
+}
+
+// OnGiveUp counts backoff which gave up.
+func (m *Metrics) OnGiveUp(name string, _ int, _ error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.get(name).GaveUp++
+}
+
+// Stats returns copy of metrics collected for the given backoff name.
+func (m *Metrics) Stats(name string) Stats {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	st, ok := m.stats[name]
+	if !ok {
+		return Stats{DelayBuckets: make([]int, len(m.buckets))}
+	}
+	s := *st
+	s.DelayBuckets = append([]int(nil), s.DelayBuckets...)
+	return s
+}
+
+// WriteTo writes all metrics in Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	names := make([]string, 0, len(m.stats))
+	for name := range m.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, c := range []struct {
+		metric, help string
+		value        func(s *Stats) int
+	}{
+		{metric: "backoff_retries_total", help: "Total number of retries.", value: func(s *Stats) int { return s.Retries }},
+		{metric: "backoff_context_done_total", help: "Total number of backoffs terminated by context.", value: func(s *Stats) int { return s.ContextDone }},
+		{metric: "backoff_gave_up_total", help: "Total number of backoffs which gave up retrying.", value: func(s *Stats) int { return s.GaveUp }},
+	} {
+		cw.write("# HELP ", c.metric, " ", c.help, "\n# TYPE ", c.metric, " counter\n")
+		for _, name := range names {
+			cw.write(c.metric, "{name=", quoteLabel(name), "} ", strconv.Itoa(c.value(m.stats[name])), "\n")
+		}
+	}
+
+	const delay = "backoff_delay_seconds"
+	cw.write("# HELP ", delay, " Delays before retries.\n# TYPE ", delay, " histogram\n")
+	for _, name := range names {
+		s := m.stats[name]
+		label := "{name=" + quoteLabel(name)
+		for i, le := range m.buckets {
+			cw.write(delay, "_bucket", label, ",le=\"", formatSeconds(le), "\"} ", strconv.Itoa(s.DelayBuckets[i]), "\n")
+		}
+		cw.write(delay, "_bucket", label, ",le=\"+Inf\"} ", strconv.Itoa(s.Retries), "\n")
+		cw.write(delay, "_sum", label, "} ", formatSeconds(s.DelaySum), "\n")
+		cw.write(delay, "_count", label, "} ", strconv.Itoa(s.Retries), "\n")
+	}
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+// ServeHTTP writes all metrics in Prometheus text exposition format, so Metrics can be registered as HTTP handler.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+// countingWriter writes strings until the first error, counting written bytes.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) write(parts ...string) {
+	for _, p := range parts {
+		if w.err != nil {
+			return
+		}
+		n, err := w.w.WriteString(p)
+		w.n += int64(n)
+		w.err = err
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) OnRetry(name string, retry int, delay time.Duration) {
+	o.events = append(o.events, name+" retry "+strconv.Itoa(retry)+" "+delay.String())
+}
+
+func (o *recordingObserver) OnContextDone(name string, _ int, err error) {
+	o.events = append(o.events, name+" context done: "+err.Error())
+}
+
+func (o *recordingObserver) OnGiveUp(name string, _ int, err error) {
+	o.events = append(o.events, name+" gave up: "+err.Error())
+}
+
+func TestBackoff_WithObserver(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	o := &recordingObserver{}
+
+	b := New(context.Background(), Config{Name: "upload", Min: time.Second, Max: time.Minute, MaxRetries: 3}, WithClock(c), WithObserver(o), WithRand(edgeRand{}))
+	for b.Ongoing() {
+		b.Wait()
+	}
+	// Termination is reported once.
+	testutil.Assert(t, !b.Ongoing())
+	testutil.Equals(t, []string{"upload retry 1 1s", "upload retry 2 2s", "upload gave up: terminated after 3 retries"}, o.events)
+
+	o.events = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Retry(ctx, Config{Name: "download", Min: time.Second, Max: time.Second}, func(context.Context) error {
+		cancel()
+		return errors.New("unavailable")
+	}, WithClock(c), WithObserver(o))
+	testutil.Equals(t, "2 errors: unavailable; context canceled", err.Error())
+	testutil.Equals(t, []string{"download context done: context canceled"}, o.events)
+
+	o.events = nil
+	err = Retry(context.Background(), Config{Name: "sync", Min: time.Second, Max: time.Second, MaxRetries: 10}, func(context.Context) error {
+		return errors.New("unavailable")
+	}, WithClock(c), WithObserver(o), WithBudget(NewRatioBudget(0, 1)))
+	testutil.Equals(t, "2 errors: unavailable; retry budget exhausted", err.Error())
+	testutil.Equals(t, []string{"sync retry 1 1s", "sync gave up: retry budget exhausted"}, o.events)
+}
+
+func TestMetrics(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	c.SetAutoAdvance(true)
+	m := NewMetrics(time.Second, 100*time.Millisecond)
+
+	fail := func(context.Context) error { return errors.New("unavailable") }
+	cfg := Config{Name: "upload", Min: 100 * time.Millisecond, Max: time.Minute, MaxRetries: 4}
+	testutil.NotOk(t, Retry(context.Background(), cfg, fail, WithClock(c), WithObserver(m), WithRand(edgeRand{})))
+	testutil.NotOk(t, Retry(context.Background(), cfg, fail, WithClock(c), WithObserver(m), WithRand(edgeRand{})))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	testutil.NotOk(t, Retry(ctx, Config{Name: `say "hi"`}, fail, WithClock(c), WithObserver(m)))
+
+	testutil.Equals(t, Stats{
+		Retries:      6,
+		GaveUp:       2,
+		DelaySum:     1400 * time.Millisecond,
+		DelayBuckets: []int{2, 6},
+	}, m.Stats("upload"))
+	testutil.Equals(t, 1, m.Stats(`say "hi"`).ContextDone)
+
+	b := &bytes.Buffer{}
+	_, err := m.WriteTo(b)
+	testutil.Ok(t, err)
+	testutil.Equals(t, `# HELP backoff_retries_total Total number of retries.
+# TYPE backoff_retries_total counter
+backoff_retries_total{name="say \"hi\""} 0
+backoff_retries_total{name="upload"} 6
+# HELP backoff_context_done_total Total number of backoffs terminated by context.
+# TYPE backoff_context_done_total counter
+backoff_context_done_total{name="say \"hi\""} 1
+backoff_context_done_total{name="upload"} 0
+# HELP backoff_gave_up_total Total number of backoffs which gave up retrying.
+# TYPE backoff_gave_up_total counter
+backoff_gave_up_total{name="say \"hi\""} 0
+backoff_gave_up_total{name="upload"} 2
+# HELP backoff_delay_seconds Delays before retries.
+# TYPE backoff_delay_seconds histogram
+backoff_delay_seconds_bucket{name="say \"hi\"",le="0.1"} 0
+backoff_delay_seconds_bucket{name="say \"hi\"",le="1"} 0
+backoff_delay_seconds_bucket{name="say \"hi\"",le="+Inf"} 0
+backoff_delay_seconds_sum{name="say \"hi\""} 0
+backoff_delay_seconds_count{name="say \"hi\""} 0
+backoff_delay_seconds_bucket{name="upload",le="0.1"} 2
+backoff_delay_seconds_bucket{name="upload",le="1"} 6
+backoff_delay_seconds_bucket{name="upload",le="+Inf"} 6
+backoff_delay_seconds_sum{name="upload"} 1.4
+backoff_delay_seconds_count{name="upload"} 6
+`, b.String())
+}
@@ -0,0 +1,41 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"time"
+)
+
+// Observer receives events of Backoff instances, e.g to log them or collect metrics (see Metrics).
+// Name is the Config.Name of the Backoff. Implementations must be safe for concurrent use, if shared.
+type Observer interface {
+	// OnRetry is called before waiting for the retry with the given number (starting from 1) for the given delay.
+	OnRetry(name string, retry int, delay time.Duration)
+	// OnContextDone is called when Backoff terminated because its context was canceled or its deadline exceeded.
+	OnContextDone(name string, retries int, err error)
+	// OnGiveUp is called when Backoff terminated for any other reason (e.g max retries, budget or circuit breaker)
+	// with error returned by Backoff.Err.
+	OnGiveUp(name string, retries int, err error)
+}
+
+// WithObserver sets Observer of Backoff events.
+func WithObserver(o Observer) Option {
+	return func(opts *options) {
+		opts.observer = o
+	}
+}
+
+// observeTermination reports termination to the observer, once until Reset.
+func (b *Backoff) observeTermination() {
+	if b.observer == nil || b.observed {
+		return
+	}
+	b.observed = true
+
+	if err := b.ctx.Err(); err != nil {
+		b.observer.OnContextDone(b.cfg.Name, b.numRetries, err)
+		return
+	}
+	b.observer.OnGiveUp(b.cfg.Name, b.numRetries, b.Err())
+}
@@ -0,0 +1,97 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+)
+
+// Class tells Retry what to do after a failed attempt.
+type Class int
+
+const (
+	// Retryable errors are retried after the next delay. This is the default for all errors.
+	Retryable Class = iota
+	// Permanent errors stop Retry immediately.
+	Permanent
+	// ResetBackoff errors are retried with the retry count and delays reset, e.g when the attempt made some progress.
+	// NOTE: Config.MaxRetries counts retries since the last reset, but Config.MaxElapsed still limits the whole Retry.
+	ResetBackoff
+)
+
+// Classifier decides what Retry does after an attempt failed with the given error.
+type Classifier func(err error) Class
+
+// WithClassifier sets Classifier used by Retry. By default all errors are Retryable.
+func WithClassifier(c Classifier) Option {
+	return func(o *options) {
+		o.classifier = c
+	}
+}
+
+// WithOnRetry sets function called by Retry before waiting for the next attempt, e.g for logging or metrics.
+// It's given the retry number (starting from 1), the delay before it and the error of the failed attempt.
+func WithOnRetry(f func(retry int, delay time.Duration, err error)) Option {
+	return func(o *options) {
+		o.onRetry = f
+	}
+}
+
+// Retry calls fn until it succeeds, fails with Permanent error or the backoff terminates, waiting between attempts as
+// configured by cfg. Each attempt gets context with Config.AttemptTimeout applied, if set. Retry hints of errors (see
+// RetryAfter) are passed to Backoff.SetMinNextDelay. It returns nil on success, otherwise the last error of fn merged
+// with the reason of termination (Backoff.Err), if any.
+//
+// It replaces the common loop:
+//
+//	b := backoff.New(ctx, cfg)
+//	for b.Ongoing() {
+//		if err = fn(ctx); err == nil {
+//			break
+//		}
+//		b.Wait()
+//	}
+func Retry(ctx context.Context, cfg Config, fn func(ctx context.Context) error, opts ...Option) merrors.Error {
+	o := applyOptions(opts)
+	b := New(ctx, cfg, opts...)
+
+	var lastErr error
+	for b.Ongoing() {
+		attemptCtx, cancel := b.AttemptContext()
+		err := fn(attemptCtx)
+		cancel()
+		if err == nil {
+			b.Success()
+			return nil
+		}
+		lastErr = err
+
+		class := Retryable
+		if o.classifier != nil {
+			class = o.classifier(err)
+		}
+		switch class {
+		case Permanent:
+			return merrors.New(err).Err()
+		case ResetBackoff:
+			b.resetRetries()
+		}
+		if hint, ok := RetryAfter(err); ok {
+			b.SetMinNextDelay(hint)
+		}
+
+		delay := b.NextDelay()
+		if !b.retryAllowed(delay) {
+			break
+		}
+		if o.onRetry != nil {
+			o.onRetry(b.NumRetries(), delay, err)
+		}
+		b.sleep(delay)
+	}
+	return merrors.New(lastErr, b.Err()).Err()
+}
@@ -0,0 +1,107 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+var (
+	errTemporary = errors.New("temporary")
+	errFatal     = errors.New("fatal")
+	errProgress  = errors.New("progress")
+)
+
+func TestRetry(t *testing.T) {
+	cfg := Config{Min: time.Millisecond, Max: time.Millisecond, MaxRetries: 3}
+
+	t.Run("succeeds after failures", func(t *testing.T) {
+		var retries []int
+		attempts := 0
+		err := Retry(context.Background(), cfg, func(context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return errTemporary
+			}
+			return nil
+		}, WithOnRetry(func(retry int, delay time.Duration, err error) {
+			testutil.Equals(t, time.Millisecond, delay)
+			testutil.Equals(t, errTemporary, err)
+			retries = append(retries, retry)
+		}))
+		testutil.Ok(t, err)
+		testutil.Equals(t, 3, attempts)
+		testutil.Equals(t, []int{1, 2}, retries)
+	})
+	t.Run("gives up", func(t *testing.T) {
+		attempts := 0
+		err := Retry(context.Background(), cfg, func(context.Context) error {
+			attempts++
+			return errTemporary
+		})
+		testutil.NotOk(t, err)
+		testutil.Equals(t, "2 errors: temporary; terminated after 3 retries", err.Error())
+		testutil.Assert(t, errors.Is(err, errTemporary))
+		testutil.Equals(t, 3, attempts)
+	})
+	t.Run("permanent", func(t *testing.T) {
+		attempts := 0
+		err := Retry(context.Background(), cfg, func(context.Context) error {
+			attempts++
+			if attempts == 2 {
+				return errFatal
+			}
+			return errTemporary
+		}, WithClassifier(func(err error) Class {
+			if errors.Is(err, errFatal) {
+				return Permanent
+			}
+			return Retryable
+		}))
+		testutil.Equals(t, "fatal", err.Error())
+		testutil.Equals(t, 2, attempts)
+	})
+	t.Run("reset", func(t *testing.T) {
+		attempts := 0
+		err := Retry(context.Background(), cfg, func(context.Context) error {
+			attempts++
+			if attempts <= 3 {
+				return errProgress
+			}
+			return errTemporary
+		}, WithClassifier(func(err error) Class {
+			if errors.Is(err, errProgress) {
+				return ResetBackoff
+			}
+			return Retryable
+		}))
+		testutil.Equals(t, "2 errors: temporary; terminated after 3 retries", err.Error())
+		// Attempt after the last reset counts as the first one, so attempts 3, 4 and 5 exhaust max retries.
+		testutil.Equals(t, 5, attempts)
+	})
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		attempts := 0
+		err := Retry(ctx, Config{Min: time.Hour, Max: time.Hour}, func(context.Context) error {
+			attempts++
+			return errTemporary
+		}, WithOnRetry(func(int, time.Duration, error) { cancel() }))
+		testutil.Equals(t, "2 errors: temporary; context canceled", err.Error())
+		testutil.Assert(t, errors.Is(err, context.Canceled))
+		testutil.Equals(t, 1, attempts)
+
+		attempts = 0
+		err = Retry(ctx, cfg, func(context.Context) error {
+			attempts++
+			return nil
+		})
+		testutil.Equals(t, "context canceled", err.Error())
+		testutil.Equals(t, 0, attempts)
+	})
+}
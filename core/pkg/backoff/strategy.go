@@ -0,0 +1,195 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Names of strategies that can be selected with Config.Strategy.
+const (
+	// StrategyExponential doubles the delay range with each retry, picking delay uniformly from [d, 2d] window
+	// capped by Max. This is the default.
+	StrategyExponential = "exponential"
+	// StrategyFullJitter picks delay uniformly from [0, d], where d is doubled with each retry and capped by Max.
+	StrategyFullJitter = "full_jitter"
+	// StrategyEqualJitter picks delay uniformly from [d/2, d], where d is doubled with each retry and capped by Max.
+	StrategyEqualJitter = "equal_jitter"
+	// StrategyDecorrelatedJitter picks delay uniformly from [Min, 3*previous delay], capped by Max. The first delay is
+	// picked from [Min, 3*Min].
+	StrategyDecorrelatedJitter = "decorrelated_jitter"
+	// StrategyConstant always waits Min.
+	StrategyConstant = "constant"
+	// StrategyLinear increases delay by Min with each retry, capped by Max.
+	StrategyLinear = "linear"
+	// StrategyFibonacci multiplies Min by consecutive Fibonacci numbers (1, 1, 2, 3, 5...), capped by Max.
+	StrategyFibonacci = "fibonacci"
+)
+
+// Rand is a source of random numbers used by strategies. *rand.Rand implements it.
+type Rand interface {
+	// Int63n returns a non-negative pseudo-random number in [0, n). It's called only with n > 0.
+	Int63n(n int64) int64
+}
+
+type globalRand struct{}
+
+func (globalRand) Int63n(n int64) int64 { return rand.Int63n(n) }
+
+// Strategy computes delays between retries. Strategy is not expected to keep any state, so the same Strategy
+// can be used by many Backoff instances.
+type Strategy interface {
+	// NextDelay returns the delay before the given retry (starting from 1). Prev is the delay returned for the previous
+	// retry or zero for the first one. All random numbers should be taken from rnd.
+	NextDelay(retry int, prev time.Duration, rnd Rand) time.Duration
+}
+
+// StrategyFunc is a function implementing Strategy.
+type StrategyFunc func(retry int, prev time.Duration, rnd Rand) time.Duration
+
+// NextDelay calls f(retry, prev, rnd).
+func (f StrategyFunc) NextDelay(retry int, prev time.Duration, rnd Rand) time.Duration {
+	return f(retry, prev, rnd)
+}
+
+// NewStrategy returns Strategy selected by cfg.Strategy, configured with cfg.Min and cfg.Max. Empty name selects
+// StrategyExponential.
+func NewStrategy(cfg Config) (Strategy, error) {
+	switch cfg.Strategy {
+	case "", StrategyExponential:
+		return Exponential(cfg.Min, cfg.Max), nil
+	case StrategyFullJitter:
+		return FullJitter(cfg.Min, cfg.Max), nil
+	case StrategyEqualJitter:
+		return EqualJitter(cfg.Min, cfg.Max), nil
+	case StrategyDecorrelatedJitter:
+		return DecorrelatedJitter(cfg.Min, cfg.Max), nil
+	case StrategyConstant:
+		return Constant(cfg.Min), nil
+	case StrategyLinear:
+		return Linear(cfg.Min, cfg.Max), nil
+	case StrategyFibonacci:
+		return Fibonacci(cfg.Min, cfg.Max), nil
+	}
+	return nil, fmt.Errorf("unknown backoff strategy %q", cfg.Strategy)
+}
+
+// Exponential returns strategy picking delay with jitter from the window starting at [min, 2*min], which is doubled with each
+// retry until it reaches max.
+func Exponential(min, max time.Duration) Strategy {
+	return StrategyFunc(func(retry int, _ time.Duration, rnd Rand) time.Duration {
+		lo, hi := min, doubleDuration(min, max)
+		for i := 1; i < retry && hi < max && hi > 0; i++ {
+			lo, hi = doubleDuration(lo, max), doubleDuration(hi, max)
+		}
+		// Handle the edge case the min and max have the same value
+		// (or due to some misconfig max is < min).
+		if lo >= hi {
+			return lo
+		}
+		return between(lo, hi, rnd)
+	})
+}
+
+// FullJitter returns strategy picking delay from [0, min*2^(retry-1)], capped by max.
+func FullJitter(min, max time.Duration) Strategy {
+	return StrategyFunc(func(retry int, _ time.Duration, rnd Rand) time.Duration {
+		return between(0, exponent(min, max, retry), rnd)
+	})
+}
+
+// EqualJitter returns strategy picking delay from [d/2, d], where d is min*2^(retry-1) capped by max.
+func EqualJitter(min, max time.Duration) Strategy {
+	return StrategyFunc(func(retry int, _ time.Duration, rnd Rand) time.Duration {
+		d := exponent(min, max, retry)
+		return between(d/2, d, rnd)
+	})
+}
+
+// DecorrelatedJitter returns strategy picking delay from [min, 3*prev], capped by max (if set), as described in
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/. The first delay is picked from [min, 3*min].
+func DecorrelatedJitter(min, max time.Duration) Strategy {
+	return StrategyFunc(func(_ int, prev time.Duration, rnd Rand) time.Duration {
+		if prev < min {
+			prev = min
+		}
+		hi := 3 * prev
+		if hi < prev {
+			// Overflow.
+			hi = time.Duration(math.MaxInt64)
+		}
+		if max > 0 && hi > max {
+			hi = max
+		}
+		return between(min, hi, rnd)
+	})
+}
+
+// Constant returns strategy always returning d.
+func Constant(d time.Duration) Strategy {
+	return StrategyFunc(func(int, time.Duration, Rand) time.Duration {
+		return d
+	})
+}
+
+// Linear returns strategy returning min*retry, capped by max.
+func Linear(min, max time.Duration) Strategy {
+	return StrategyFunc(func(retry int, _ time.Duration, _ Rand) time.Duration {
+		d := min
+		for i := 1; i < retry && d < max && d > 0; i++ {
+			d += min
+		}
+		return capped(min, d, max)
+	})
+}
+
+// Fibonacci returns strategy returning min multiplied by retry-th Fibonacci number, capped by max.
+func Fibonacci(min, max time.Duration) Strategy {
+	return StrategyFunc(func(retry int, _ time.Duration, _ Rand) time.Duration {
+		a, b := time.Duration(0), min
+		for i := 1; i < retry && b < max && b > 0; i++ {
+			a, b = b, a+b
+		}
+		return capped(min, b, max)
+	})
+}
+
+// exponent returns min*2^(retry-1), capped by max.
+func exponent(min, max time.Duration, retry int) time.Duration {
+	d := min
+	for i := 1; i < retry && d < max && d > 0; i++ {
+		d = doubleDuration(d, max)
+	}
+	return capped(min, d, max)
+}
+
+// capped returns d capped by max, but not lower than min, so misconfigured max lower than min is ignored.
+func capped(min, d, max time.Duration) time.Duration {
+	if d > max {
+		d = max
+	}
+	if d < min {
+		d = min
+	}
+	return d
+}
+
+// between returns random duration from [lo, hi), or lo if range is empty.
+func between(lo, hi time.Duration, rnd Rand) time.Duration {
+	if lo >= hi {
+		return lo
+	}
+	return lo + time.Duration(rnd.Int63n(int64(hi-lo)))
+}
+
+func doubleDuration(value time.Duration, max time.Duration) time.Duration {
+	value = value * 2
+	if value <= max {
+		return value
+	}
+	return max
+}
@@ -0,0 +1,118 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package backoff
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+// edgeRand returns the lowest or the highest possible random number.
+type edgeRand struct{ high bool }
+
+func (r edgeRand) Int63n(n int64) int64 {
+	if r.high {
+		return n - 1
+	}
+	return 0
+}
+
+func delays(t *testing.T, cfg Config, rnd Rand, n int) []time.Duration {
+	t.Helper()
+
+	testutil.Ok(t, cfg.Validate())
+	b := New(context.Background(), cfg, WithRand(rnd))
+
+	var ret []time.Duration
+	for i := 0; i < n; i++ {
+		ret = append(ret, b.NextDelay())
+	}
+	return ret
+}
+
+func TestStrategies(t *testing.T) {
+	const ms = time.Millisecond
+
+	for _, tcase := range []struct {
+		strategy  string
+		low, high []time.Duration
+		min, max  time.Duration
+	}{
+		{
+			strategy: StrategyExponential, min: 100 * ms, max: 1000 * ms,
+			low:  []time.Duration{100 * ms, 200 * ms, 400 * ms, 800 * ms, 800 * ms},
+			high: []time.Duration{200*ms - 1, 400*ms - 1, 800*ms - 1, 1000*ms - 1, 1000*ms - 1},
+		},
+		{
+			strategy: StrategyFullJitter, min: 100 * ms, max: 1000 * ms,
+			low:  []time.Duration{0, 0, 0, 0, 0},
+			high: []time.Duration{100*ms - 1, 200*ms - 1, 400*ms - 1, 800*ms - 1, 1000*ms - 1},
+		},
+		{
+			strategy: StrategyEqualJitter, min: 100 * ms, max: 1000 * ms,
+			low:  []time.Duration{50 * ms, 100 * ms, 200 * ms, 400 * ms, 500 * ms},
+			high: []time.Duration{100*ms - 1, 200*ms - 1, 400*ms - 1, 800*ms - 1, 1000*ms - 1},
+		},
+		{
+			strategy: StrategyDecorrelatedJitter, min: 100 * ms, max: 1000 * ms,
+			low:  []time.Duration{100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms},
+			high: []time.Duration{300*ms - 1, 900*ms - 4, 1000*ms - 1, 1000*ms - 1, 1000*ms - 1},
+		},
+		{
+			strategy: StrategyConstant, min: 100 * ms, max: 1000 * ms,
+			low:  []time.Duration{100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms},
+			high: []time.Duration{100 * ms, 100 * ms, 100 * ms, 100 * ms, 100 * ms},
+		},
+		{
+			strategy: StrategyLinear, min: 100 * ms, max: 350 * ms,
+			low:  []time.Duration{100 * ms, 200 * ms, 300 * ms, 350 * ms, 350 * ms},
+			high: []time.Duration{100 * ms, 200 * ms, 300 * ms, 350 * ms, 350 * ms},
+		},
+		{
+			strategy: StrategyFibonacci, min: 100 * ms, max: 1000 * ms,
+			low:  []time.Duration{100 * ms, 100 * ms, 200 * ms, 300 * ms, 500 * ms, 800 * ms, 1000 * ms},
+			high: []time.Duration{100 * ms, 100 * ms, 200 * ms, 300 * ms, 500 * ms, 800 * ms, 1000 * ms},
+		},
+	} {
+		t.Run(tcase.strategy, func(t *testing.T) {
+			cfg := Config{Min: tcase.min, Max: tcase.max, Strategy: tcase.strategy}
+			testutil.Equals(t, tcase.low, delays(t, cfg, edgeRand{}, len(tcase.low)))
+			testutil.Equals(t, tcase.high, delays(t, cfg, edgeRand{high: true}, len(tcase.high)))
+		})
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testutil.Ok(t, Config{Min: time.Second, Max: time.Minute}.Validate())
+	testutil.NotOk(t, Config{Min: time.Second, Max: time.Minute, Strategy: "quadratic"}.Validate())
+	testutil.NotOk(t, Config{Min: time.Minute, Max: time.Second}.Validate())
+	testutil.NotOk(t, Config{Min: -time.Second}.Validate())
+	testutil.NotOk(t, Config{MaxRetries: -1}.Validate())
+
+	// Unknown strategy falls back to exponential.
+	b := New(context.Background(), Config{Min: time.Second, Max: time.Second, Strategy: "quadratic"})
+	testutil.Equals(t, time.Second, b.NextDelay())
+}
+
+func TestBackoff_WithStrategy(t *testing.T) {
+	b := New(context.Background(), Config{}, WithStrategy(StrategyFunc(func(retry int, prev time.Duration, _ Rand) time.Duration {
+		return prev + time.Duration(retry)
+	})))
+	testutil.Equals(t, time.Duration(1), b.NextDelay())
+	testutil.Equals(t, time.Duration(3), b.NextDelay())
+	b.Reset()
+	testutil.Equals(t, time.Duration(1), b.NextDelay())
+}
+
+func TestDecorrelatedJitter_FirstRetry(t *testing.T) {
+	s := DecorrelatedJitter(100*time.Millisecond, 0)
+	testutil.Equals(t, 100*time.Millisecond, s.NextDelay(1, 0, edgeRand{}))
+	testutil.Equals(t, 300*time.Millisecond-1, s.NextDelay(1, 0, edgeRand{high: true}))
+
+	// Without max, delay keeps growing.
+	testutil.Equals(t, 3*time.Second-1, s.NextDelay(2, time.Second, edgeRand{high: true}))
+}
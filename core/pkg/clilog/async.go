@@ -0,0 +1,184 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// OverflowPolicy specifies what AsyncLogger does when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks Log until there is a space in the queue. This is the default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest removes the oldest queued record to make space for the new one.
+	OverflowDropOldest
+	// OverflowDropNewest drops the record being logged.
+	OverflowDropNewest
+)
+
+// WithQueueSize sets how many records AsyncLogger can queue before applying overflow policy. Default is 1024.
+func WithQueueSize(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+// WithOverflowPolicy sets what AsyncLogger does when its queue is full. OverflowBlock is used by default.
+func WithOverflowPolicy(p OverflowPolicy) Option {
+	return func(o *options) {
+		o.overflow = p
+	}
+}
+
+// ErrClosed is returned by AsyncLogger methods after it was closed.
+var ErrClosed = errors.New("clilog: logger closed")
+
+// AsyncLogger is a Logger that encodes records in the calling goroutine, but writes them in the background goroutine
+// from a bounded queue, so slow writers do not slow down callers. Number of dropped records (if any) is logged as a
+// separate warning record. Flush or Close must be called to make sure all records were written.
+type AsyncLogger struct {
+	l logger
+
+	mtx     sync.Mutex
+	cond    *sync.Cond
+	queue   [][]byte
+	dropped int
+	writing bool
+	closed  bool
+	err     error
+
+	done chan struct{}
+}
+
+// NewAsync returns a AsyncLogger that encodes keyvals the same way as New and writes them to the Writer in the background.
+// Each log event still produces no more than one call to w.Write. Writer does not need to be safe for concurrent use.
+func NewAsync(w io.Writer, opts ...Option) *AsyncLogger {
+	a := &AsyncLogger{
+		l:    logger{w: w, opts: applyOptions(w, opts)},
+		done: make(chan struct{}),
+	}
+	a.cond = sync.NewCond(&a.mtx)
+	go a.run()
+	return a
+}
+
+// Log encodes keyvals and queues them for writing. It returns ErrClosed if logger was closed.
+// Writer errors are returned by Flush and Close.
+func (a *AsyncLogger) Log(keyvals ...interface{}) error {
+	if a.l.filtered(keyvals) {
+		return nil
+	}
+
+	return a.l.encode(keyvals, func(record []byte) error {
+		a.mtx.Lock()
+		defer a.mtx.Unlock()
+
+		for !a.closed && len(a.queue) >= a.l.opts.queueSize {
+			switch a.l.opts.overflow {
+			case OverflowDropNewest:
+				a.dropped++
+				return nil
+			case OverflowDropOldest:
+				a.queue[0] = nil
+				a.queue = a.queue[1:]
+				a.dropped++
+			default:
+				a.cond.Wait()
+			}
+		}
+		if a.closed {
+			return ErrClosed
+		}
+
+		a.queue = append(a.queue, append([]byte(nil), record...))
+		a.cond.Broadcast()
+		return nil
+	})
+}
+
+func (a *AsyncLogger) run() {
+	defer close(a.done)
+
+	for {
+		a.mtx.Lock()
+		for len(a.queue) == 0 && a.dropped == 0 && !a.closed {
+			a.cond.Wait()
+		}
+		if len(a.queue) == 0 && a.dropped == 0 {
+			// Closed and drained.
+			a.mtx.Unlock()
+			return
+		}
+
+		var record []byte
+		if a.dropped > 0 {
+			record = a.droppedRecord(a.dropped)
+			a.dropped = 0
+		} else {
+			record = a.queue[0]
+			a.queue[0] = nil
+			a.queue = a.queue[1:]
+		}
+		a.writing = true
+		// Wake up Log calls blocked on full queue.
+		a.cond.Broadcast()
+		a.mtx.Unlock()
+
+		_, err := a.l.w.Write(record)
+
+		a.mtx.Lock()
+		a.writing = false
+		if err != nil && a.err == nil {
+			a.err = err
+		}
+		a.cond.Broadcast()
+		a.mtx.Unlock()
+	}
+}
+
+// droppedRecord returns record informing about dropped records.
+func (a *AsyncLogger) droppedRecord(dropped int) []byte {
+	var record []byte
+	_ = a.l.encode([]interface{}{
+		a.l.opts.levelKey, LevelWarn,
+		"msg", "clilog: queue was full, dropped " + strconv.Itoa(dropped) + " records",
+	}, func(r []byte) error {
+		record = append(record, r...)
+		return nil
+	})
+	return record
+}
+
+// Flush waits until all queued records are written. It returns the first writer error that occurred since the
+// previous Flush, if any.
+func (a *AsyncLogger) Flush() error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	for len(a.queue) > 0 || a.dropped > 0 || a.writing {
+		a.cond.Wait()
+	}
+	err := a.err
+	a.err = nil
+	return err
+}
+
+// Close flushes all queued records and stops the background goroutine. Log calls after Close return ErrClosed.
+// It's safe to call Close multiple times.
+func (a *AsyncLogger) Close() error {
+	a.mtx.Lock()
+	a.closed = true
+	a.cond.Broadcast()
+	a.mtx.Unlock()
+
+	<-a.done
+	return a.Flush()
+}
@@ -0,0 +1,119 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog_test
+
+import (
+	"bytes"
+	stderrors "errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+// gatedWriter blocks writes until gate is closed.
+type gatedWriter struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+
+	mtx sync.Mutex
+	b   bytes.Buffer
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.gate
+
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.b.Write(p)
+}
+
+func (w *gatedWriter) String() string {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return w.b.String()
+}
+
+func TestAsyncLogger(t *testing.T) {
+	defer testutil.TolerantVerifyLeak(t)
+
+	w := newGatedWriter()
+	close(w.gate)
+
+	l := clilog.NewAsync(w, clilog.WithQueueSize(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			testutil.Ok(t, l.Log("msg", "record", "i", i))
+		}(i)
+	}
+	wg.Wait()
+	testutil.Ok(t, l.Flush())
+	testutil.Equals(t, 10, bytes.Count([]byte(w.String()), []byte("record")))
+
+	testutil.Ok(t, l.Log("msg", "last"))
+	testutil.Ok(t, l.Close())
+	testutil.Ok(t, l.Close())
+	testutil.Assert(t, bytes.HasSuffix([]byte(w.String()), []byte("last\n")))
+	testutil.Equals(t, clilog.ErrClosed, l.Log("msg", "after close"))
+}
+
+func TestAsyncLogger_Overflow(t *testing.T) {
+	defer testutil.TolerantVerifyLeak(t)
+
+	for _, tcase := range []struct {
+		policy   clilog.OverflowPolicy
+		expected string
+	}{
+		{
+			policy:   clilog.OverflowDropNewest,
+			expected: "0\nWARN: clilog: queue was full, dropped 3 records\n1\n2\n",
+		},
+		{
+			policy:   clilog.OverflowDropOldest,
+			expected: "0\nWARN: clilog: queue was full, dropped 3 records\n4\n5\n",
+		},
+	} {
+		t.Run(strconv.Itoa(int(tcase.policy)), func(t *testing.T) {
+			w := newGatedWriter()
+			l := clilog.NewAsync(w, clilog.WithQueueSize(2), clilog.WithOverflowPolicy(tcase.policy))
+
+			// Wait until first record is being written, so it's not in the queue anymore.
+			testutil.Ok(t, l.Log("i", 0))
+			<-w.started
+			for i := 1; i < 6; i++ {
+				testutil.Ok(t, l.Log("i", i))
+			}
+			close(w.gate)
+
+			testutil.Ok(t, l.Close())
+			testutil.Equals(t, tcase.expected, w.String())
+		})
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, stderrors.New("broken pipe") }
+
+func TestAsyncLogger_WriterError(t *testing.T) {
+	defer testutil.TolerantVerifyLeak(t)
+
+	l := clilog.NewAsync(errWriter{})
+	testutil.Ok(t, l.Log("msg", "lost"))
+	testutil.NotOk(t, l.Flush())
+	testutil.Ok(t, l.Flush())
+	testutil.Ok(t, l.Close())
+}
@@ -9,8 +9,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/efficientgo/tools/core/pkg/merrors"
 )
@@ -39,21 +46,185 @@ var bufPool = sync.Pool{
 	},
 }
 
+// Format represents the way records are rendered.
+type Format int
+
+const (
+	// FormatHuman renders values only, separated by ': ', with multi errors pretty printed at the end of the record.
+	// This is the default format.
+	FormatHuman Format = iota
+	// FormatLogfmt renders records as space separated key=value pairs.
+	FormatLogfmt
+	// FormatJSON renders each record as a single JSON object.
+	FormatJSON
+)
+
+var formatNames = map[Format]string{
+	FormatHuman:  "human",
+	FormatLogfmt: "logfmt",
+	FormatJSON:   "json",
+}
+
+func (f Format) String() string {
+	if n, ok := formatNames[f]; ok {
+		return n
+	}
+	return "Format(" + strconv.Itoa(int(f)) + ")"
+}
+
+// ParseFormat returns the Format for the given name. This is useful when format is selected by a flag.
+func ParseFormat(name string) (Format, error) {
+	for f, n := range formatNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown clilog format %q, expected one of human, logfmt or json", name)
+}
+
+type options struct {
+	format    Format
+	levelKey  string
+	minLevel  Level
+	colorMode ColorMode
+	maxDepth  int
+	maxLength int
+	width     int
+	queueSize int
+	overflow  OverflowPolicy
+
+	dedupErrors bool
+
+	statusMode     StatusMode
+	statusInterval time.Duration
+
+	// color is resolved from colorMode and the writer.
+	color bool
+}
+
+// Option configures Logger and Encoder.
+type Option func(*options)
+
+// WithFormat sets the format records are rendered in. FormatHuman is used by default.
+func WithFormat(f Format) Option {
+	return func(o *options) {
+		o.format = f
+	}
+}
+
+// WithLevelKey sets the key under which records carry their Level. "level" is used by default.
+func WithLevelKey(key string) Option {
+	return func(o *options) {
+		o.levelKey = key
+	}
+}
+
+// WithMinLevel makes Logger drop records with level lower than the given one. Records without level are never dropped.
+func WithMinLevel(l Level) Option {
+	return func(o *options) {
+		o.minLevel = l
+	}
+}
+
+// WithColor sets when level markers are colored. ColorAuto is used by default. Colors are used only in FormatHuman.
+func WithColor(mode ColorMode) Option {
+	return func(o *options) {
+		o.colorMode = mode
+	}
+}
+
+// WithMaxDepth sets how deep nested arrays, slices, maps and structs are rendered. Deeper values are replaced with "...".
+// Default is 5.
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxDepth = n
+		}
+	}
+}
+
+// WithMaxLength sets how many elements of arrays, slices, maps and structs are rendered. The rest is summarized
+// with number of omitted elements. Default is 50.
+func WithMaxLength(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.maxLength = n
+		}
+	}
+}
+
+// WithWidth sets width that records written in FormatHuman are wrapped to. Long lines are broken on spaces with hanging
+// indentation, multi error trees are kept aligned. By default (zero) width of the terminal is used if writer is a terminal,
+// otherwise no wrapping is done. Negative width disables wrapping.
+func WithWidth(n int) Option {
+	return func(o *options) {
+		o.width = n
+	}
+}
+
+// WithDedupErrors makes multi errors printed with repeated errors collapsed, e.g "connection refused (x487)".
+// See merrors.Dedup for details.
+func WithDedupErrors() Option {
+	return func(o *options) {
+		o.dedupErrors = true
+	}
+}
+
+func applyOptions(w io.Writer, opts []Option) options {
+	o := options{levelKey: "level", maxDepth: 5, maxLength: 50, queueSize: 1024}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.color = o.format == FormatHuman && useColor(w, o.colorMode)
+	if o.format != FormatHuman {
+		o.width = 0
+	} else if f, ok := terminal(w); ok && o.width == 0 {
+		o.width = terminalWidth(f)
+	}
+	return o
+}
+
 type logger struct {
-	w io.Writer
+	w    io.Writer
+	opts options
 }
 
 // New returns a logger that encodes keyvals to the Writer in
 // CLI friendly format. Each log event produces no more than one call to w.Write.
 // The passed Writer must be safe for concurrent use by multiple goroutines if
 // the returned Logger will be used concurrently.
-func New(w io.Writer) Logger {
-	return &logger{w}
+func New(w io.Writer, opts ...Option) Logger {
+	return &logger{w: w, opts: applyOptions(w, opts)}
 }
 
 func (l logger) Log(keyvals ...interface{}) error {
+	if l.filtered(keyvals) {
+		return nil
+	}
+
+	return l.encode(keyvals, func(record []byte) error {
+		// The Logger interface requires implementations to be safe for concurrent
+		// use by multiple goroutines. For this implementation that means making
+		// only one call to l.w.Write() for each call to Log.
+		_, err := l.w.Write(record)
+		return err
+	})
+}
+
+// filtered returns true if record should be dropped due to its level.
+func (l logger) filtered(keyvals []interface{}) bool {
+	if l.opts.minLevel == LevelDebug {
+		return false
+	}
+	lvl, ok := recordLevel(l.opts.levelKey, keyvals)
+	return ok && lvl < l.opts.minLevel
+}
+
+// encode encodes keyvals as a single record and passes it to write. The record is valid only until write returns.
+func (l logger) encode(keyvals []interface{}, write func(record []byte) error) error {
 	buf := bufPool.Get().(*buf)
 	buf.Reset()
+	buf.opts = l.opts
 	defer bufPool.Put(buf)
 
 	if err := buf.EncodeKeyvals(keyvals...); err != nil {
@@ -64,14 +235,7 @@ func (l logger) Log(keyvals ...interface{}) error {
 	if err := buf.EndRecord(); err != nil {
 		return err
 	}
-
-	// The Logger interface requires implementations to be safe for concurrent
-	// use by multiple goroutines. For this implementation that means making
-	// only one call to l.w.Write() for each call to Log.
-	if _, err := l.w.Write(buf.Bytes()); err != nil {
-		return err
-	}
-	return nil
+	return write(buf.Bytes())
 }
 
 // MarshalKeyvals returns the clilog encoding of keyvals, a variadic sequence
@@ -89,25 +253,43 @@ type Encoder struct {
 	w       io.Writer
 	scratch bytes.Buffer
 	needSep bool
+	opts    options
+
+	// layout and col are used for wrapping records to the configured width.
+	layout bytes.Buffer
+	col    int
 
 	errs []merrors.Error
 }
 
 // NewEncoder returns a new clilog Encoder that writes to w.
-func NewEncoder(w io.Writer) *Encoder {
-	return &Encoder{w: w}
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	return &Encoder{w: w, opts: applyOptions(w, opts)}
 }
 
 var (
-	sep     = []byte(": ")
-	newline = []byte("\n")
-	null    = []byte("null")
+	sep       = []byte(": ")
+	newline   = []byte("\n")
+	null      = []byte("null")
+	space     = []byte(" ")
+	equals    = []byte("=")
+	comma     = []byte(",")
+	colon     = []byte(":")
+	openObj   = []byte("{")
+	closeObj  = []byte("}")
+	openList  = []byte("[")
+	closeList = []byte("]")
 )
 
-// EncodeKeyval writes the clilog encoding of key and value to the stream. A
-// single space is written before the second and subsequent keys in a record.
+// EncodeKeyval writes the clilog encoding of key and value to the stream.
+// In FormatHuman the key is ignored and ': ' is written before the second and subsequent values in a record.
 // Nothing is written if a non-nil error is returned.
-func (enc *Encoder) EncodeKeyval(_, value interface{}) error {
+func (enc *Encoder) EncodeKeyval(key, value interface{}) error {
+	switch enc.opts.format {
+	case FormatLogfmt, FormatJSON:
+		return enc.encodeStructuredKeyval(key, value)
+	}
+
 	if e, ok := value.(error); ok {
 		if errs, ok := merrors.AsMulti(e); ok {
 			enc.errs = append(enc.errs, errs)
@@ -116,21 +298,92 @@ func (enc *Encoder) EncodeKeyval(_, value interface{}) error {
 	}
 
 	enc.scratch.Reset()
-
-	// Naive right now, print values only (:
 	if enc.needSep {
 		if _, err := enc.scratch.Write(sep); err != nil {
 			return err
 		}
 	}
-	if err := writeValue(&enc.scratch, value); err != nil {
+	if err := enc.writeValue(key, value); err != nil {
+		return err
+	}
+	err := enc.writeScratch()
+	enc.needSep = true
+	return err
+}
+
+// writeScratch writes scratch buffer to the stream, wrapped to fit the width if configured.
+func (enc *Encoder) writeScratch() error {
+	if enc.opts.width <= 0 {
+		_, err := enc.w.Write(enc.scratch.Bytes())
+		return err
+	}
+	enc.layout.Reset()
+	enc.col = wrap(&enc.layout, enc.scratch.Bytes(), enc.col, enc.opts.width)
+	_, err := enc.w.Write(enc.layout.Bytes())
+	return err
+}
+
+// encodeStructuredKeyval writes key and value in machine readable format. Multi errors are written in place
+// as nested list (JSON) or indexed keys (logfmt) of their errors.
+func (enc *Encoder) encodeStructuredKeyval(key, value interface{}) error {
+	k, err := keyString(key)
+	if err != nil {
+		return err
+	}
+
+	enc.scratch.Reset()
+	if err := enc.writeFieldSep(); err != nil {
+		return err
+	}
+
+	if e, ok := value.(error); ok {
+		if merr, ok := merrors.AsMulti(e); ok {
+			if enc.opts.dedupErrors {
+				merr = merrors.Dedup(merr)
+			}
+			if err := writeMultiError(&enc.scratch, &enc.opts, k, merr); err != nil {
+				return err
+			}
+			_, err := enc.w.Write(enc.scratch.Bytes())
+			enc.needSep = true
+			return err
+		}
+	}
+
+	if err := writeKey(&enc.scratch, enc.opts.format, k); err != nil {
+		return err
+	}
+	if err := enc.writeValue(k, value); err != nil {
 		return err
 	}
-	_, err := enc.w.Write(enc.scratch.Bytes())
+	_, err = enc.w.Write(enc.scratch.Bytes())
 	enc.needSep = true
 	return err
 }
 
+// writeValue writes value to the scratch buffer. Values under level key are written as level markers.
+func (enc *Encoder) writeValue(key, value interface{}) error {
+	if k, err := keyString(key); err == nil && k == enc.opts.levelKey {
+		if l, ok := levelOf(value); ok {
+			return writeLevel(&enc.scratch, enc.opts.format, l, enc.opts.color)
+		}
+	}
+	return writeValue(&enc.scratch, &enc.opts, value)
+}
+
+func (enc *Encoder) writeFieldSep() error {
+	var err error
+	switch {
+	case enc.opts.format == FormatJSON && !enc.needSep:
+		_, err = enc.scratch.Write(openObj)
+	case enc.opts.format == FormatJSON:
+		_, err = enc.scratch.Write(comma)
+	case enc.needSep:
+		_, err = enc.scratch.Write(space)
+	}
+	return err
+}
+
 // EncodeKeyvals writes the logfmt encoding of keyvals to the stream. Keyvals
 // is a variadic sequence of alternating keys and values. Keys of unsupported
 // type are skipped along with their corresponding value. Values of
@@ -163,6 +416,17 @@ func (enc *Encoder) EncodeKeyvals(keyvals ...interface{}) error {
 
 // EndRecord ends the log record.
 func (enc *Encoder) EndRecord() error {
+	if enc.opts.format == FormatJSON {
+		enc.scratch.Reset()
+		if !enc.needSep {
+			_, _ = enc.scratch.Write(openObj)
+		}
+		_, _ = enc.scratch.Write(closeObj)
+		if _, err := enc.w.Write(enc.scratch.Bytes()); err != nil {
+			return err
+		}
+	}
+
 	if len(enc.errs) > 0 {
 		enc.scratch.Reset()
 		if enc.needSep {
@@ -172,18 +436,21 @@ func (enc *Encoder) EndRecord() error {
 		}
 
 		merr := merrors.Merge(enc.errs)
+		if enc.opts.dedupErrors {
+			merr = merrors.Dedup(merr)
+		}
 		if err := merrors.PrettyPrint(&enc.scratch, merr); err != nil {
 			return err
 		}
 
-		if _, err := enc.w.Write(enc.scratch.Bytes()); err != nil {
+		if err := enc.writeScratch(); err != nil {
 			return err
 		}
 	}
 
 	_, err := enc.w.Write(newline)
 	if err == nil {
-		enc.needSep = false
+		enc.Reset()
 	}
 	return err
 }
@@ -191,6 +458,8 @@ func (enc *Encoder) EndRecord() error {
 // Reset resets the Encoder to the beginning of a new record.
 func (enc *Encoder) Reset() {
 	enc.needSep = false
+	enc.col = 0
+	enc.errs = enc.errs[:0]
 }
 
 // MarshalerError represents an error encountered while marshaling a value.
@@ -211,59 +480,302 @@ var ErrUnsupportedKeyType = errors.New("unsupported key type")
 // unsupported type.
 var ErrUnsupportedValueType = errors.New("unsupported value type")
 
-func writeValue(w io.Writer, value interface{}) error {
+func writeValue(w io.Writer, o *options, value interface{}) error {
+	return writeValueDepth(w, o, value, 0)
+}
+
+// writeValueDepth writes value nested in depth composite values.
+func writeValueDepth(w io.Writer, o *options, value interface{}, depth int) error {
+	f := o.format
 	switch v := value.(type) {
 	case nil:
-		return writeBytesValue(w, null)
+		return writeNull(w)
 	case string:
-		return writeStringValue(w, v, true)
+		return writeStringValue(w, f, v, true)
 	case []byte:
-		return writeBytesValue(w, v)
+		return writeBytesValue(w, f, v)
 	case encoding.TextMarshaler:
 		vb, err := safeMarshal(v)
 		if err != nil {
 			return err
 		}
 		if vb == nil {
-			vb = null
+			return writeNull(w)
 		}
-		return writeBytesValue(w, vb)
+		return writeBytesValue(w, f, vb)
 	case error:
 		se, ok := safeError(v)
-		return writeStringValue(w, se, ok)
+		return writeStringValue(w, f, se, ok)
 	case fmt.Stringer:
 		ss, ok := safeString(v)
-		return writeStringValue(w, ss, ok)
+		return writeStringValue(w, f, ss, ok)
 	default:
 		rvalue := reflect.ValueOf(value)
 		switch rvalue.Kind() {
-		case reflect.Array, reflect.Chan, reflect.Func, reflect.Map, reflect.Slice, reflect.Struct:
-			return ErrUnsupportedValueType
+		case reflect.Array, reflect.Map, reflect.Slice, reflect.Struct:
+			if depth == 0 {
+				return writeCompositeValue(w, o, rvalue)
+			}
+			return writeComposite(w, o, rvalue, depth+1)
+		case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			// Nothing meaningful can be printed for those, print just a type.
+			return writeStringValue(w, f, rvalue.Type().String(), true)
 		case reflect.Ptr:
 			if rvalue.IsNil() {
-				return writeBytesValue(w, null)
+				return writeNull(w)
+			}
+			return writeValueDepth(w, o, rvalue.Elem().Interface(), depth)
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			// Numbers and booleans never need quoting, also in JSON.
+			_, err := fmt.Fprintf(w, "%v", v)
+			return err
+		case reflect.Float32, reflect.Float64:
+			if fv := rvalue.Float(); f == FormatJSON && (math.IsNaN(fv) || math.IsInf(fv, 0)) {
+				// NaN and infinities are not valid JSON numbers.
+				return writeStringValue(w, f, fmt.Sprintf("%v", v), true)
 			}
-			return writeValue(w, rvalue.Elem().Interface())
+			_, err := fmt.Fprintf(w, "%v", v)
+			return err
 		}
-		return writeStringValue(w, fmt.Sprintf("%v", v), true) //nolint
+		return writeStringValue(w, f, fmt.Sprintf("%v", v), true) //nolint
 	}
 }
 
-func writeStringValue(w io.Writer, value string, ok bool) error {
+func writeNull(w io.Writer) error {
+	_, err := w.Write(null)
+	return err
+}
+
+func writeStringValue(w io.Writer, f Format, value string, ok bool) error {
 	var err error
-	if ok && value == "null" {
-		_, err = io.WriteString(w, `"null"`)
-	} else {
-		_, err = io.WriteString(w, value)
+	switch f {
+	case FormatLogfmt:
+		if (ok && value == "null") || needsQuoting(value) {
+			_, err = io.WriteString(w, strconv.Quote(value))
+		} else {
+			_, err = io.WriteString(w, value)
+		}
+	case FormatJSON:
+		err = writeJSONString(w, value)
+	default:
+		if ok && value == "null" {
+			_, err = io.WriteString(w, `"null"`)
+		} else {
+			_, err = io.WriteString(w, value)
+		}
 	}
 	return err
 }
 
-func writeBytesValue(w io.Writer, value []byte) error {
+func writeBytesValue(w io.Writer, f Format, value []byte) error {
+	if f != FormatHuman {
+		return writeStringValue(w, f, string(value), true)
+	}
 	_, err := w.Write(value)
 	return err
 }
 
+// needsQuoting returns true if given logfmt value has to be quoted to be parsed back unambiguously.
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeJSONString writes s as JSON string. Unlike encoding/json it does not escape HTML characters.
+func writeJSONString(w io.Writer, s string) error {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// keyString returns string representation of the key. Only keys of string, error, fmt.Stringer and
+// encoding.TextMarshaler types are supported.
+func keyString(key interface{}) (string, error) {
+	switch k := key.(type) {
+	case string:
+		return k, nil
+	case encoding.TextMarshaler:
+		kb, err := safeMarshal(k)
+		if err != nil || kb == nil {
+			return "", ErrUnsupportedKeyType
+		}
+		return string(kb), nil
+	case error:
+		ke, ok := safeError(k)
+		if !ok {
+			return "", ErrUnsupportedKeyType
+		}
+		return ke, nil
+	case fmt.Stringer:
+		ks, ok := safeString(k)
+		if !ok {
+			return "", ErrUnsupportedKeyType
+		}
+		return ks, nil
+	}
+	return "", ErrUnsupportedKeyType
+}
+
+func writeKey(w io.Writer, f Format, key string) error {
+	if f == FormatJSON {
+		if err := writeJSONString(w, key); err != nil {
+			return err
+		}
+		_, err := w.Write(colon)
+		return err
+	}
+
+	// Logfmt keys cannot be quoted, so replace all characters that would make the record ambiguous.
+	if key == "" {
+		key = "_"
+	}
+	if _, err := io.WriteString(w, strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)); err != nil {
+		return err
+	}
+	_, err := w.Write(equals)
+	return err
+}
+
+// writeMultiError writes multi error (including nested ones) under given key. In JSON errors are written as (nested) list of
+// error messages. In logfmt each error is written as separate pair with index appended to the key, e.g err.0=... err.1.0=...
+// Labels of annotated errors are written as {"message": ..., "labels": {...}} objects in JSON and err.0.<label>=... pairs in logfmt.
+func writeMultiError(w io.Writer, o *options, key string, merr merrors.Error) error {
+	if o.format == FormatJSON {
+		if err := writeKey(w, o.format, key); err != nil {
+			return err
+		}
+		return writeJSONErrorList(w, o, merr)
+	}
+
+	for i, err := range merr.Errors() {
+		if i > 0 {
+			if _, err := w.Write(space); err != nil {
+				return err
+			}
+		}
+		k := key + "." + strconv.Itoa(i)
+		if inner, ok := merrors.AsMulti(err); ok {
+			if err := writeMultiError(w, o, k, inner); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeKey(w, o.format, k); err != nil {
+			return err
+		}
+		if err := writeValue(w, o, err); err != nil {
+			return err
+		}
+
+		labels := merrors.Labels(err)
+		keys := make([]string, 0, len(labels))
+		for l := range labels {
+			keys = append(keys, l)
+		}
+		sort.Strings(keys)
+		for _, l := range keys {
+			if _, err := w.Write(space); err != nil {
+				return err
+			}
+			if err := writeKey(w, o.format, k+"."+l); err != nil {
+				return err
+			}
+			if err := writeStringValue(w, o.format, labels[l], true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeJSONErrorList(w io.Writer, o *options, merr merrors.Error) error {
+	if _, err := w.Write(openList); err != nil {
+		return err
+	}
+	for i, err := range merr.Errors() {
+		if i > 0 {
+			if _, err := w.Write(comma); err != nil {
+				return err
+			}
+		}
+		if inner, ok := merrors.AsMulti(err); ok {
+			if err := writeJSONErrorList(w, o, inner); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeJSONError(w, o, err); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(closeList)
+	return err
+}
+
+// writeJSONError writes error message, or object with message and labels if error was annotated.
+func writeJSONError(w io.Writer, o *options, err error) error {
+	labels := merrors.Labels(err)
+	if len(labels) == 0 {
+		return writeValue(w, o, err)
+	}
+
+	if _, err := w.Write(openObj); err != nil {
+		return err
+	}
+	if err := writeKey(w, o.format, "message"); err != nil {
+		return err
+	}
+	if err := writeValue(w, o, err); err != nil {
+		return err
+	}
+	if _, err := w.Write(comma); err != nil {
+		return err
+	}
+	if err := writeKey(w, o.format, "labels"); err != nil {
+		return err
+	}
+	if err := writeValue(w, o, labels); err != nil {
+		return err
+	}
+	_, err = w.Write(closeObj)
+	return err
+}
+
 func safeError(err error) (s string, ok bool) {
 	defer func() {
 		if panicVal := recover(); panicVal != nil {
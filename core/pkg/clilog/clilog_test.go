@@ -0,0 +1,221 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestLogger_Formats(t *testing.T) {
+	// Wrap it so Add cannot add inner errors in.
+	merr := merrors.New(stderrors.New("err1"), fmt.Errorf("wrap: %w", merrors.New(stderrors.New("err2"), stderrors.New("err3")).Err())).Err()
+
+	for _, tcase := range []struct {
+		format   clilog.Format
+		keyvals  []interface{}
+		expected string
+	}{
+		{
+			format:   clilog.FormatHuman,
+			keyvals:  []interface{}{"msg", "compacting", "file", "a b.txt", "size", 12},
+			expected: "compacting: a b.txt: 12\n",
+		},
+		{
+			format:   clilog.FormatLogfmt,
+			keyvals:  []interface{}{"msg", "compacting", "file", "a b.txt", "size", 12, "ok", true, "empty", "", "nil", nil, "str", "null"},
+			expected: "msg=compacting file=\"a b.txt\" size=12 ok=true empty=\"\" nil=null str=\"null\"\n",
+		},
+		{
+			format:   clilog.FormatJSON,
+			keyvals:  []interface{}{"msg", "compacting \"quoted\"", "file", "a b.txt", "size", 12, "ok", true, "nil", nil},
+			expected: `{"msg":"compacting \"quoted\"","file":"a b.txt","size":12,"ok":true,"nil":null}` + "\n",
+		},
+		{
+			format:   clilog.FormatJSON,
+			expected: "{}\n",
+		},
+		{
+			format:   clilog.FormatHuman,
+			keyvals:  []interface{}{"msg", "failed", "err", merr},
+			expected: "failed: 2 errors:\n\terr1\n\t2 errors:\n\t\terr2\n\t\terr3\n",
+		},
+		{
+			format:   clilog.FormatLogfmt,
+			keyvals:  []interface{}{"msg", "failed", "err", merr},
+			expected: "msg=failed err.0=err1 err.1.0=err2 err.1.1=err3\n",
+		},
+		{
+			format:   clilog.FormatJSON,
+			keyvals:  []interface{}{"msg", "failed", "err", merr},
+			expected: `{"msg":"failed","err":["err1",["err2","err3"]]}` + "\n",
+		},
+	} {
+		t.Run(tcase.format.String(), func(t *testing.T) {
+			b := &bytes.Buffer{}
+			testutil.Ok(t, clilog.New(b, clilog.WithFormat(tcase.format)).Log(tcase.keyvals...))
+			testutil.Equals(t, tcase.expected, b.String())
+
+			if tcase.format == clilog.FormatJSON {
+				testutil.Assert(t, json.Valid(b.Bytes()), "invalid JSON %v", b.String())
+			}
+		})
+	}
+}
+
+func TestLogger_MultiErrorNotLeakingToNextRecord(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clilog.New(b)
+
+	testutil.Ok(t, l.Log("msg", "failed", "err", merrors.New(stderrors.New("err1"), stderrors.New("err2")).Err()))
+	testutil.Ok(t, l.Log("msg", "done"))
+	testutil.Equals(t, "failed: 2 errors:\n\terr1\n\terr2\ndone\n", b.String())
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range []clilog.Format{clilog.FormatHuman, clilog.FormatLogfmt, clilog.FormatJSON} {
+		parsed, err := clilog.ParseFormat(f.String())
+		testutil.Ok(t, err)
+		testutil.Equals(t, f, parsed)
+	}
+	_, err := clilog.ParseFormat("yaml")
+	testutil.NotOk(t, err)
+}
+
+func TestLogger_Levels(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clilog.New(b, clilog.WithMinLevel(clilog.LevelInfo))
+
+	testutil.Ok(t, l.Log("level", "debug", "msg", "skipped"))
+	testutil.Ok(t, l.Log("level", clilog.LevelWarn, "msg", "disk almost full"))
+	testutil.Ok(t, l.Log("level", "error", "msg", "compaction failed"))
+	testutil.Ok(t, l.Log("msg", "no level"))
+	testutil.Ok(t, l.Log("level", "custom", "msg", "unknown level"))
+	testutil.Equals(t, "WARN: disk almost full\nERROR: compaction failed\nno level\ncustom: unknown level\n", b.String())
+
+	b.Reset()
+	l = clilog.New(b, clilog.WithColor(clilog.ColorAlways), clilog.WithLevelKey("lvl"))
+	testutil.Ok(t, l.Log("lvl", "Error", "msg", "compaction failed"))
+	testutil.Equals(t, "\033[31mERROR\033[0m: compaction failed\n", b.String())
+
+	b.Reset()
+	l = clilog.New(b, clilog.WithFormat(clilog.FormatLogfmt), clilog.WithColor(clilog.ColorAlways), clilog.WithMinLevel(clilog.LevelWarn))
+	testutil.Ok(t, l.Log("level", "info", "msg", "skipped"))
+	testutil.Ok(t, l.Log("level", "WARNING", "msg", "disk almost full"))
+	testutil.Equals(t, "level=warn msg=\"disk almost full\"\n", b.String())
+}
+
+type testConfig struct {
+	Name   string
+	Files  []string
+	Limits map[string]int
+	Parent *testConfig
+
+	secret string
+}
+
+type panickingStringer struct{}
+
+func (panickingStringer) String() string { panic("oops") }
+
+func TestLogger_CompositeValues(t *testing.T) {
+	cfg := testConfig{
+		Name:   "compact",
+		Files:  []string{"a.txt", "b.txt"},
+		Limits: map[string]int{"z": 1, "a": 2},
+		Parent: &testConfig{Name: "root"},
+		secret: "hidden",
+	}
+
+	for _, tcase := range []struct {
+		opts     []clilog.Option
+		keyvals  []interface{}
+		expected string
+	}{
+		{
+			keyvals:  []interface{}{"msg", "files", "files", []string{"a.txt", "b.txt"}, "empty", []int(nil)},
+			expected: "files: [a.txt, b.txt]: []\n",
+		},
+		{
+			keyvals:  []interface{}{"cfg", cfg},
+			expected: "{Name: compact, Files: [a.txt, b.txt], Limits: {a: 2, z: 1}, Parent: {Name: root, Files: [], Limits: {}, Parent: null}}\n",
+		},
+		{
+			opts:     []clilog.Option{clilog.WithMaxDepth(1), clilog.WithMaxLength(2)},
+			keyvals:  []interface{}{"cfg", cfg, "nums", [4]int{1, 2, 3, 4}},
+			expected: "{Name: compact, Files: ..., ... (2 more)}: [1, 2, ... (2 more)]\n",
+		},
+		{
+			opts:     []clilog.Option{clilog.WithFormat(clilog.FormatLogfmt)},
+			keyvals:  []interface{}{"files", []string{"a.txt", "b.txt"}, "limits", map[int]bool{2: false, 1: true}},
+			expected: "files=\"[a.txt, b.txt]\" limits=\"{1: true, 2: false}\"\n",
+		},
+		{
+			opts:     []clilog.Option{clilog.WithFormat(clilog.FormatJSON), clilog.WithMaxLength(3)},
+			keyvals:  []interface{}{"cfg", cfg, "nums", []int{1, 2, 3, 4}},
+			expected: `{"cfg":{"Name":"compact","Files":["a.txt","b.txt"],"Limits":{"a":2,"z":1},"...":"... (1 more)"},"nums":[1,2,3,"... (1 more)"]}` + "\n",
+		},
+		{
+			keyvals:  []interface{}{"ch", make(chan int), "stringers", []fmt.Stringer{panickingStringer{}}},
+			expected: "chan int: [PANIC:oops]\n",
+		},
+	} {
+		t.Run("", func(t *testing.T) {
+			b := &bytes.Buffer{}
+			testutil.Ok(t, clilog.New(b, tcase.opts...).Log(tcase.keyvals...))
+			testutil.Equals(t, tcase.expected, b.String())
+		})
+	}
+}
+
+func TestLogger_DedupErrors(t *testing.T) {
+	errRefused := stderrors.New("connection refused")
+	merr := merrors.New(errRefused, errRefused, stderrors.New("not found"), errRefused).Err()
+
+	b := &bytes.Buffer{}
+	testutil.Ok(t, clilog.New(b, clilog.WithDedupErrors()).Log("msg", "upload failed", "err", merr))
+	testutil.Equals(t, "upload failed: 2 errors:\n\tconnection refused (x3)\n\tnot found\n", b.String())
+
+	b.Reset()
+	testutil.Ok(t, clilog.New(b, clilog.WithDedupErrors(), clilog.WithFormat(clilog.FormatJSON)).Log("msg", "upload failed", "err", merr))
+	testutil.Equals(t, `{"msg":"upload failed","err":["connection refused (x3)","not found"]}`+"\n", b.String())
+}
+
+func TestLogger_AnnotatedErrors(t *testing.T) {
+	merr := merrors.New(
+		merrors.Annotate(stderrors.New("connection refused"), "shard", "3", "op", "upload"),
+		stderrors.New("not found"),
+	).Err()
+
+	for _, tcase := range []struct {
+		format   clilog.Format
+		expected string
+	}{
+		{
+			format:   clilog.FormatHuman,
+			expected: "sync failed: 2 errors:\n\tconnection refused [op=upload shard=3]\n\tnot found\n",
+		},
+		{
+			format:   clilog.FormatLogfmt,
+			expected: "msg=\"sync failed\" err.0=\"connection refused\" err.0.op=upload err.0.shard=3 err.1=\"not found\"\n",
+		},
+		{
+			format:   clilog.FormatJSON,
+			expected: `{"msg":"sync failed","err":[{"message":"connection refused","labels":{"op":"upload","shard":"3"}},"not found"]}` + "\n",
+		},
+	} {
+		t.Run(tcase.format.String(), func(t *testing.T) {
+			b := &bytes.Buffer{}
+			testutil.Ok(t, clilog.New(b, clilog.WithFormat(tcase.format)).Log("msg", "sync failed", "err", merr))
+			testutil.Equals(t, tcase.expected, b.String())
+		})
+	}
+}
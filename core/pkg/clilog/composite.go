@@ -0,0 +1,216 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var (
+	compositeSep = []byte(", ")
+	fieldSep     = []byte(": ")
+	ellipsis     = []byte("...")
+)
+
+// writeCompositeValue writes array, slice, map or struct value in deterministic, readable way, e.g [a, b], {k1: v1, k2: v2}.
+// In FormatJSON those are written as JSON arrays and objects. In FormatLogfmt the human readable form is written as a single
+// (quoted) value.
+func writeCompositeValue(w io.Writer, o *options, rvalue reflect.Value) error {
+	b, err := safeComposite(o, rvalue)
+	if err != nil {
+		return err
+	}
+	if o.format == FormatLogfmt {
+		return writeStringValue(w, o.format, string(b), true)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// safeComposite renders composite value into separate buffer, so panic in any of nested String, Error or MarshalText
+// methods does not leave partially written value behind.
+func safeComposite(o *options, rvalue reflect.Value) (b []byte, err error) {
+	ro := *o
+	if ro.format == FormatLogfmt {
+		ro.format = FormatHuman
+	}
+
+	defer func() {
+		if panicVal := recover(); panicVal != nil {
+			buf := &bytes.Buffer{}
+			err = writeStringValue(buf, ro.format, fmt.Sprintf("PANIC:%v", panicVal), true)
+			b = buf.Bytes()
+		}
+	}()
+
+	buf := &bytes.Buffer{}
+	if err := writeComposite(buf, &ro, rvalue, 1); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeComposite(w io.Writer, o *options, rvalue reflect.Value, depth int) error {
+	json := o.format == FormatJSON
+
+	if depth > o.maxDepth {
+		if json {
+			return writeJSONString(w, string(ellipsis))
+		}
+		_, err := w.Write(ellipsis)
+		return err
+	}
+
+	switch rvalue.Kind() {
+	case reflect.Array, reflect.Slice:
+		if rvalue.Kind() == reflect.Slice && rvalue.IsNil() && json {
+			return writeNull(w)
+		}
+		if _, err := w.Write(openList); err != nil {
+			return err
+		}
+		n := rvalue.Len()
+		for i := 0; i < n && i < o.maxLength; i++ {
+			if err := writeCompositeSep(w, json, i); err != nil {
+				return err
+			}
+			if err := writeValueDepth(w, o, rvalue.Index(i).Interface(), depth); err != nil {
+				return err
+			}
+		}
+		if err := writeOmitted(w, json, false, n, o.maxLength); err != nil {
+			return err
+		}
+		_, err := w.Write(closeList)
+		return err
+
+	case reflect.Map:
+		if rvalue.IsNil() && json {
+			return writeNull(w)
+		}
+		if _, err := w.Write(openObj); err != nil {
+			return err
+		}
+
+		// Sort entries by rendered key for deterministic output.
+		type entry struct {
+			key   string
+			value reflect.Value
+		}
+		ko := *o
+		ko.format = FormatHuman
+		entries := make([]entry, 0, rvalue.Len())
+		iter := rvalue.MapRange()
+		for iter.Next() {
+			kb := &bytes.Buffer{}
+			if err := writeValueDepth(kb, &ko, iter.Key().Interface(), depth); err != nil {
+				return err
+			}
+			entries = append(entries, entry{key: kb.String(), value: iter.Value()})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+		for i, e := range entries {
+			if i >= o.maxLength {
+				break
+			}
+			if err := writeCompositeSep(w, json, i); err != nil {
+				return err
+			}
+			if err := writeField(w, json, e.key); err != nil {
+				return err
+			}
+			if err := writeValueDepth(w, o, e.value.Interface(), depth); err != nil {
+				return err
+			}
+		}
+		if err := writeOmitted(w, json, true, len(entries), o.maxLength); err != nil {
+			return err
+		}
+		_, err := w.Write(closeObj)
+		return err
+
+	case reflect.Struct:
+		if _, err := w.Write(openObj); err != nil {
+			return err
+		}
+
+		// Only exported fields can be accessed.
+		n := 0
+		t := rvalue.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath != "" {
+				continue
+			}
+			if n < o.maxLength {
+				if err := writeCompositeSep(w, json, n); err != nil {
+					return err
+				}
+				if err := writeField(w, json, t.Field(i).Name); err != nil {
+					return err
+				}
+				if err := writeValueDepth(w, o, rvalue.Field(i).Interface(), depth); err != nil {
+					return err
+				}
+			}
+			n++
+		}
+		if err := writeOmitted(w, json, true, n, o.maxLength); err != nil {
+			return err
+		}
+		_, err := w.Write(closeObj)
+		return err
+	}
+	return ErrUnsupportedValueType
+}
+
+func writeCompositeSep(w io.Writer, json bool, i int) error {
+	if i == 0 {
+		return nil
+	}
+	var err error
+	if json {
+		_, err = w.Write(comma)
+	} else {
+		_, err = w.Write(compositeSep)
+	}
+	return err
+}
+
+func writeField(w io.Writer, json bool, name string) error {
+	if json {
+		return writeKey(w, FormatJSON, name)
+	}
+	if _, err := io.WriteString(w, name); err != nil {
+		return err
+	}
+	_, err := w.Write(fieldSep)
+	return err
+}
+
+// writeOmitted writes summary of elements that were not written due to length limit, if any.
+func writeOmitted(w io.Writer, json, object bool, n, maxLength int) error {
+	if n <= maxLength {
+		return nil
+	}
+	summary := "... (" + strconv.Itoa(n-maxLength) + " more)"
+	if !json {
+		_, err := io.WriteString(w, ", "+summary)
+		return err
+	}
+	if _, err := w.Write(comma); err != nil {
+		return err
+	}
+	if object {
+		if err := writeKey(w, FormatJSON, string(ellipsis)); err != nil {
+			return err
+		}
+	}
+	return writeJSONString(w, summary)
+}
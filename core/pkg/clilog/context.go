@@ -0,0 +1,136 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// Valuer generates a log value. When passed to With or WithPrefix as a value (odd indexes), it represents
+// a dynamic value which is evaluated on each Log call.
+type Valuer func() interface{}
+
+var (
+	// DefaultTimestamp is a Valuer that returns the current wall clock time in RFC3339Nano format.
+	DefaultTimestamp = TimestampFormat(time.Now, time.RFC3339Nano)
+	// DefaultTimestampUTC is a Valuer that returns the current UTC time in RFC3339Nano format.
+	DefaultTimestampUTC = TimestampFormat(func() time.Time { return time.Now().UTC() }, time.RFC3339Nano)
+	// DefaultCaller is a Valuer that returns the file and line where the Log method was invoked.
+	// It can only be used with With and WithPrefix.
+	DefaultCaller = Caller(3)
+)
+
+// Timestamp returns a Valuer that invokes the given function to get the time.
+func Timestamp(t func() time.Time) Valuer {
+	return func() interface{} { return t() }
+}
+
+// TimestampFormat returns a Valuer that invokes the given function to get the time and formats it using given layout.
+func TimestampFormat(t func() time.Time, layout string) Valuer {
+	return func() interface{} { return t().Format(layout) }
+}
+
+// Caller returns a Valuer that returns a file:line description of the caller. The depth parameter is relative to the
+// Valuer function itself, so 3 means the caller of the Log method of the Logger returned by With or WithPrefix.
+func Caller(depth int) Valuer {
+	return func() interface{} {
+		_, file, line, _ := runtime.Caller(depth)
+		return filepath.Base(file) + ":" + strconv.Itoa(line)
+	}
+}
+
+type contextLogger struct {
+	logger    Logger
+	keyvals   []interface{}
+	hasValuer bool
+}
+
+// With returns a new Logger with keyvals appended to its context. Context is prepended to keyvals passed to each Log call.
+// Values that are Valuer are evaluated on each Log call. Each Log call still produces only one call to underlying Logger.
+func With(logger Logger, keyvals ...interface{}) Logger {
+	if len(keyvals) == 0 {
+		return logger
+	}
+	l := newContextLogger(logger)
+	kvs := append(l.keyvals, keyvals...)
+	if len(kvs)%2 != 0 {
+		kvs = append(kvs, nil)
+	}
+	return &contextLogger{
+		logger: l.logger,
+		// Limit capacity so that next With call reallocates instead of overwriting shared array.
+		keyvals:   kvs[:len(kvs):len(kvs)],
+		hasValuer: l.hasValuer || containsValuer(keyvals),
+	}
+}
+
+// WithPrefix returns a new Logger with keyvals prepended to its context, so before any keyvals bound with With.
+// Values that are Valuer are evaluated on each Log call. Each Log call still produces only one call to underlying Logger.
+func WithPrefix(logger Logger, keyvals ...interface{}) Logger {
+	if len(keyvals) == 0 {
+		return logger
+	}
+	l := newContextLogger(logger)
+	n := len(l.keyvals) + len(keyvals)
+	if len(keyvals)%2 != 0 {
+		n++
+	}
+	kvs := make([]interface{}, 0, n)
+	kvs = append(kvs, keyvals...)
+	if len(kvs)%2 != 0 {
+		kvs = append(kvs, nil)
+	}
+	kvs = append(kvs, l.keyvals...)
+	return &contextLogger{
+		logger:    l.logger,
+		keyvals:   kvs,
+		hasValuer: l.hasValuer || containsValuer(keyvals),
+	}
+}
+
+func newContextLogger(logger Logger) *contextLogger {
+	if c, ok := logger.(*contextLogger); ok {
+		return c
+	}
+	return &contextLogger{logger: logger}
+}
+
+// Log evaluates Valuers (if any) and passes context keyvals together with given ones to the underlying Logger.
+func (l *contextLogger) Log(keyvals ...interface{}) error {
+	kvs := append(l.keyvals, keyvals...)
+	if len(kvs)%2 != 0 {
+		kvs = append(kvs, nil)
+	}
+	if l.hasValuer {
+		// If no keyvals were appended above then we must copy l.keyvals so that future log events
+		// will re-evaluate the stored Valuers.
+		if len(keyvals) == 0 {
+			kvs = append([]interface{}{}, l.keyvals...)
+		}
+		bindValues(kvs[:len(l.keyvals)])
+	}
+	return l.logger.Log(kvs...)
+}
+
+// bindValues replaces all Valuers in values position with their returned value.
+// NOTE: It has to be called directly from Log method to keep Caller depth correct.
+func bindValues(keyvals []interface{}) {
+	for i := 1; i < len(keyvals); i += 2 {
+		if v, ok := keyvals[i].(Valuer); ok {
+			keyvals[i] = v()
+		}
+	}
+}
+
+func containsValuer(keyvals []interface{}) bool {
+	for i := 1; i < len(keyvals); i += 2 {
+		if _, ok := keyvals[i].(Valuer); ok {
+			return true
+		}
+	}
+	return false
+}
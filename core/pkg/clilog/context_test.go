@@ -0,0 +1,63 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestWith(t *testing.T) {
+	w := &countingWriter{}
+	l := clilog.New(w, clilog.WithFormat(clilog.FormatLogfmt))
+
+	cmd := clilog.With(l, "cmd", "compact")
+	file := clilog.With(cmd, "file", "a.txt")
+	prefixed := clilog.WithPrefix(file, "level", "warn")
+
+	testutil.Ok(t, cmd.Log("msg", "starting"))
+	testutil.Ok(t, file.Log("msg", "processing"))
+	testutil.Ok(t, prefixed.Log("msg", "slow"))
+	// Context of parent logger should not be affected.
+	testutil.Ok(t, cmd.Log("msg", "done"))
+
+	testutil.Equals(t, "cmd=compact msg=starting\n"+
+		"cmd=compact file=a.txt msg=processing\n"+
+		"level=warn cmd=compact file=a.txt msg=slow\n"+
+		"cmd=compact msg=done\n", w.String())
+	testutil.Equals(t, 4, w.writes)
+}
+
+func TestWith_Valuers(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	calls := 0
+	l := clilog.WithPrefix(clilog.New(b, clilog.WithFormat(clilog.FormatLogfmt)),
+		"ts", clilog.TimestampFormat(func() time.Time {
+			calls++
+			return now.Add(time.Duration(calls) * time.Second)
+		}, time.RFC3339),
+		"caller", clilog.DefaultCaller,
+	)
+
+	testutil.Ok(t, l.Log())
+	testutil.Ok(t, l.Log("msg", "second"))
+	testutil.Equals(t, "ts=2021-01-01T00:00:01Z caller=context_test.go:59\n"+
+		"ts=2021-01-01T00:00:02Z caller=context_test.go:60 msg=second\n", b.String())
+}
@@ -12,6 +12,26 @@ package clilog
 // * No key printing.
 // * Values separated with ': '
 // * Support for pretty printing multi errors (including nested ones) in format of (<something>: <err1>; <err2>; ...; <errN>)
-// * TODO(bwplotka): Support for multiple multilines.
+// * Long records wrapped to the terminal width (or `WithWidth`) with hanging indentation, multi error trees kept aligned.
+//
+// The same Logger can be switched to machine readable output (e.g when running in CI) using `WithFormat` option:
+//
+// * `FormatLogfmt`: key=value pairs, multi errors as indexed keys (err.0=<err1> err.1=<err2>).
+// * `FormatJSON`: one JSON object per line, multi errors as (nested) lists of error messages.
+//
+// Values under the level key (compatible with `github.com/go-kit/kit/log/level`) are rendered as level markers (e.g `WARN`, `ERROR`),
+// colored if the writer is a terminal. Records below level set by `WithMinLevel` are dropped.
+//
+// Arrays, slices, maps and structs are rendered in deterministic way (e.g `[a, b]`, `{key: value}`) with sorted map keys,
+// limited by `WithMaxDepth` and `WithMaxLength` options.
+//
+// Common keyvals can be bound to the Logger using `With` and `WithPrefix`. `Valuer` values (e.g `DefaultTimestamp` or
+// `DefaultCaller`) are evaluated on each Log call.
+//
+// `NewAsync` returns Logger that writes records from a bounded queue in the background, with configurable overflow policy.
+// Use `Flush` and `Close` to make sure all records were written.
+//
+// `NewStatus` returns writer that keeps a live status line (e.g progress) redrawn below records on terminals, falling back to
+// throttled plain records on other writers.
 //
 // Compatible with `github.com/go-kit/kit/log.Logger`
@@ -0,0 +1,78 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// hangingIndent is added to the indentation of the wrapped line for its continuation lines.
+	hangingIndent = "    "
+	// tabIndent replaces leading tabs (e.g multi error tree levels) when wrapping, so the column can be tracked.
+	tabIndent = "    "
+)
+
+// wrap writes text that starts at column col to dst, breaking lines on spaces so they fit width where possible.
+// Words longer than the available space are never broken. Continuation lines are indented with the indentation
+// of the line they continue plus hangingIndent. It returns the column after the written text.
+func wrap(dst *bytes.Buffer, text []byte, col, width int) int {
+	// Indentation of the record line text continues.
+	indent := ""
+	for i, line := range strings.Split(string(text), "\n") {
+		if i > 0 {
+			dst.WriteByte('\n')
+			trimmed := strings.TrimLeft(line, "\t ")
+			indent = strings.Replace(line[:len(line)-len(trimmed)], "\t", tabIndent, -1)
+			line = trimmed
+
+			dst.WriteString(indent)
+			col = len(indent)
+		}
+		col = wrapLine(dst, line, col, width, indent+hangingIndent)
+	}
+	return col
+}
+
+// wrapLine writes single line. The first word continues text already written, so it's never moved to the next line.
+func wrapLine(dst *bytes.Buffer, line string, col, width int, contIndent string) int {
+	for i, word := range strings.Split(line, " ") {
+		n := visibleLen(word)
+		if i == 0 {
+			dst.WriteString(word)
+			col += n
+			continue
+		}
+		if col+1+n > width && col > len(contIndent) {
+			dst.WriteByte('\n')
+			dst.WriteString(contIndent)
+			dst.WriteString(word)
+			col = len(contIndent) + n
+			continue
+		}
+		dst.WriteByte(' ')
+		dst.WriteString(word)
+		col += 1 + n
+	}
+	return col
+}
+
+// visibleLen returns number of characters that are visible in terminal, so without ANSI color sequences.
+func visibleLen(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\033' {
+			if end := strings.IndexByte(s[i:], 'm'); end >= 0 {
+				i += end
+				continue
+			}
+		}
+		if s[i] < utf8.RuneSelf || utf8.RuneStart(s[i]) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,45 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog_test
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestLogger_Width(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := clilog.New(b, clilog.WithWidth(30), clilog.WithColor(clilog.ColorAlways))
+
+	testutil.Ok(t, l.Log("level", "error", "msg", "compaction of block failed", "reason", "overlapping time ranges detected"))
+	testutil.Equals(t, "\033[31mERROR\033[0m: compaction of block\n"+
+		"    failed: overlapping time\n"+
+		"    ranges detected\n", b.String())
+
+	b.Reset()
+	merr := merrors.New(
+		stderrors.New("upload of a.txt failed: connection refused"),
+		fmt.Errorf("wrap: %w", merrors.New(stderrors.New("short"), stderrors.New("upload of b.txt failed: connection reset by peer")).Err()),
+	).Err()
+	testutil.Ok(t, l.Log("msg", "sync", "err", merr))
+	testutil.Equals(t, "sync: 2 errors:\n"+
+		"    upload of a.txt failed:\n"+
+		"        connection refused\n"+
+		"    2 errors:\n"+
+		"        short\n"+
+		"        upload of b.txt\n"+
+		"            failed: connection\n"+
+		"            reset by peer\n", b.String())
+
+	// Non terminal writer without width option is not wrapped.
+	b.Reset()
+	testutil.Ok(t, clilog.New(b).Log("msg", "compaction of block failed", "reason", "overlapping time ranges detected"))
+	testutil.Equals(t, "compaction of block failed: overlapping time ranges detected\n", b.String())
+}
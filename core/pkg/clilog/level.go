@@ -0,0 +1,155 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Level represents severity of the log record. Records carry level as value of the level key (by default "level"),
+// which is compatible with `github.com/go-kit/kit/log/level` package.
+type Level int
+
+const (
+	// LevelDebug is the lowest level. Setting it as minimum level does not filter anything.
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if n, ok := levelNames[l]; ok {
+		return n
+	}
+	return "Level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// ParseLevel returns the Level for the given name (case insensitive). Both "warn" and "warning" are accepted.
+func ParseLevel(name string) (Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown clilog level %q, expected one of debug, info, warn or error", name)
+}
+
+// levelOf returns level represented by value. Values of Level type and anything that renders
+// (e.g go-kit level.Value) to a known level name are recognized.
+func levelOf(value interface{}) (Level, bool) {
+	switch v := value.(type) {
+	case Level:
+		_, ok := levelNames[v]
+		return v, ok
+	case string:
+		l, err := ParseLevel(v)
+		return l, err == nil
+	case fmt.Stringer:
+		s, ok := safeString(v)
+		if !ok {
+			return 0, false
+		}
+		l, err := ParseLevel(s)
+		return l, err == nil
+	}
+	return 0, false
+}
+
+// ColorMode specifies when level markers are colored.
+type ColorMode int
+
+const (
+	// ColorAuto enables colors only if the writer is a terminal and NO_COLOR environment variable is not set or empty.
+	// This is the default.
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+const colorReset = "\033[0m"
+
+var levelColors = map[Level]string{
+	LevelDebug: "\033[90m",
+	LevelInfo:  "\033[34m",
+	LevelWarn:  "\033[33m",
+	LevelError: "\033[31m",
+}
+
+func useColor(w io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal returns true if w (or writer wrapped by Status) is a file pointing to character device like a terminal.
+func isTerminal(w io.Writer) bool {
+	_, ok := terminal(w)
+	return ok
+}
+
+// terminal returns terminal file w writes to, if any.
+func terminal(w io.Writer) (*os.File, bool) {
+	if s, ok := w.(*Status); ok {
+		w = s.w
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return nil, false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, false
+	}
+	return f, fi.Mode()&os.ModeCharDevice != 0
+}
+
+// writeLevel writes level marker. In FormatHuman it's upper case name, optionally colored. Other formats use lower case name.
+func writeLevel(w io.Writer, f Format, l Level, color bool) error {
+	if f != FormatHuman {
+		return writeStringValue(w, f, l.String(), true)
+	}
+	marker := strings.ToUpper(l.String())
+	if color {
+		marker = levelColors[l] + marker + colorReset
+	}
+	_, err := io.WriteString(w, marker)
+	return err
+}
+
+// recordLevel returns level of the record defined by keyvals if any.
+func recordLevel(levelKey string, keyvals []interface{}) (Level, bool) {
+	for i := 0; i < len(keyvals)-1; i += 2 {
+		if k, err := keyString(keyvals[i]); err != nil || k != levelKey {
+			continue
+		}
+		if l, ok := levelOf(keyvals[i+1]); ok {
+			return l, true
+		}
+	}
+	return 0, false
+}
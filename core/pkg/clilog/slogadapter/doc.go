@@ -0,0 +1,15 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package slogadapter
+
+// Adapters between `log/slog` and `github.com/go-kit/kit/log.Logger` compatible loggers (e.g `clilog.Logger`). Requires Go 1.21+.
+//
+// * `NewHandler` returns `slog.Handler` rendering records with clilog, including pretty printed multi errors:
+//
+//	logger := slog.New(slogadapter.NewHandler(os.Stderr, nil))
+//	logger.WithGroup("compact").Warn("block skipped", "block", id, "err", merr)
+//
+// * `NewLogger` exposes `slog.Logger` as go-kit compatible Logger expected by `clilog`, `runutil` and `logerrcapture`:
+//
+//	runutil.RetryWithLog(slogadapter.NewLogger(slog.Default()), interval, stopc, f)
@@ -0,0 +1,147 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+//go:build go1.21
+// +build go1.21
+
+package slogadapter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+)
+
+// Handler is a slog.Handler that renders records using clilog. Records are logged with "level" and "msg" keys
+// followed by attributes. Group names are prefixed to the attribute keys with dot (e.g "group.key"), the same
+// as slog.TextHandler does. Record time is not rendered, as it's usually just a noise in CLI output.
+type Handler struct {
+	logger clilog.Logger
+	hopts  slog.HandlerOptions
+
+	keyvals []interface{}
+	groups  []string
+	// prefix is groups joined with dots, with trailing dot.
+	prefix string
+}
+
+// NewHandler returns Handler writing to w. Level, AddSource and ReplaceAttr handler options are respected, nil hopts
+// means defaults. clilog options configure rendering; only records with key "level" are understood as leveled.
+func NewHandler(w io.Writer, hopts *slog.HandlerOptions, opts ...clilog.Option) *Handler {
+	h := &Handler{logger: clilog.New(w, opts...)}
+	if hopts != nil {
+		h.hopts = *hopts
+	}
+	return h
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.hopts.Level != nil {
+		min = h.hopts.Level.Level()
+	}
+	return level >= min
+}
+
+// Handle renders the record as a single clilog record.
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	keyvals := make([]interface{}, 0, 4+len(h.keyvals)+2*r.NumAttrs())
+
+	builtin := []slog.Attr{slog.Any(slog.LevelKey, r.Level), slog.String(slog.MessageKey, r.Message)}
+	if h.hopts.AddSource && r.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		builtin = append(builtin, slog.String(slog.SourceKey, filepath.Base(f.File)+":"+strconv.Itoa(f.Line)))
+	}
+	for _, a := range builtin {
+		if h.hopts.ReplaceAttr != nil {
+			a = h.hopts.ReplaceAttr(nil, a)
+		}
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		v := a.Value.Any()
+		if l, ok := v.(slog.Level); ok {
+			v = toCLILevel(l)
+		}
+		keyvals = append(keyvals, a.Key, v)
+	}
+
+	keyvals = append(keyvals, h.keyvals...)
+	r.Attrs(func(a slog.Attr) bool {
+		keyvals = h.appendAttr(keyvals, h.prefix, h.groups, a)
+		return true
+	})
+	return h.logger.Log(keyvals...)
+}
+
+// WithAttrs returns a new Handler with given attributes bound.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.keyvals = make([]interface{}, len(h.keyvals), len(h.keyvals)+2*len(attrs))
+	copy(h2.keyvals, h.keyvals)
+	for _, a := range attrs {
+		h2.keyvals = h.appendAttr(h2.keyvals, h.prefix, h.groups, a)
+	}
+	return &h2
+}
+
+// WithGroup returns a new Handler that prefixes keys of all following attributes with the group name.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+	h2.prefix = h.prefix + name + "."
+	return &h2
+}
+
+// appendAttr appends attribute as key and value, flattening groups.
+func (h *Handler) appendAttr(keyvals []interface{}, prefix string, groups []string, a slog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if h.hopts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.hopts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return keyvals
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return keyvals
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			keyvals = h.appendAttr(keyvals, prefix, groups, ga)
+		}
+		return keyvals
+	}
+	return append(keyvals, prefix+a.Key, a.Value.Any())
+}
+
+// toCLILevel maps slog levels to the closest lower clilog level.
+func toCLILevel(l slog.Level) clilog.Level {
+	switch {
+	case l >= slog.LevelError:
+		return clilog.LevelError
+	case l >= slog.LevelWarn:
+		return clilog.LevelWarn
+	case l >= slog.LevelInfo:
+		return clilog.LevelInfo
+	}
+	return clilog.LevelDebug
+}
@@ -0,0 +1,110 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+//go:build go1.21
+// +build go1.21
+
+package slogadapter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+)
+
+type logger struct {
+	l *slog.Logger
+}
+
+// NewLogger returns go-kit compatible clilog.Logger that logs to the given slog.Logger. Value of "msg" key becomes record message
+// and value of "level" key (clilog.Level, go-kit level value or level name) becomes record level (Info if missing).
+// The rest of keyvals become record attributes.
+func NewLogger(l *slog.Logger) clilog.Logger {
+	return &logger{l: l}
+}
+
+func (l *logger) Log(keyvals ...interface{}) error {
+	if len(keyvals)%2 == 1 {
+		keyvals = append(keyvals, nil)
+	}
+
+	var (
+		level = slog.LevelInfo
+		msg   string
+		attrs = make([]slog.Attr, 0, len(keyvals)/2)
+	)
+	for i := 0; i < len(keyvals); i += 2 {
+		k, v := keyString(keyvals[i]), keyvals[i+1]
+		switch k {
+		case "msg":
+			if s, ok := v.(string); ok {
+				msg = s
+				continue
+			}
+		case "level":
+			if lvl, ok := toSlogLevel(v); ok {
+				level = lvl
+				continue
+			}
+		}
+		attrs = append(attrs, slog.Any(k, v))
+	}
+
+	ctx := context.Background()
+	if !l.l.Enabled(ctx, level) {
+		return nil
+	}
+
+	// Skip runtime.Callers and this method, so the source is caller of Log.
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.AddAttrs(attrs...)
+	return l.l.Handler().Handle(ctx, r)
+}
+
+func keyString(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case fmt.Stringer:
+		return k.String()
+	}
+	return fmt.Sprintf("%v", key)
+}
+
+func toSlogLevel(v interface{}) (slog.Level, bool) {
+	var (
+		l   clilog.Level
+		err error
+	)
+	switch lv := v.(type) {
+	case clilog.Level:
+		l = lv
+	case string:
+		l, err = clilog.ParseLevel(lv)
+	case fmt.Stringer:
+		l, err = clilog.ParseLevel(lv.String())
+	default:
+		return 0, false
+	}
+	if err != nil {
+		return 0, false
+	}
+
+	switch l {
+	case clilog.LevelDebug:
+		return slog.LevelDebug, true
+	case clilog.LevelInfo:
+		return slog.LevelInfo, true
+	case clilog.LevelWarn:
+		return slog.LevelWarn, true
+	case clilog.LevelError:
+		return slog.LevelError, true
+	}
+	return 0, false
+}
@@ -0,0 +1,54 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+//go:build go1.21
+// +build go1.21
+
+package slogadapter_test
+
+import (
+	"bytes"
+	stderrors "errors"
+	"log/slog"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+	"github.com/efficientgo/tools/core/pkg/clilog/slogadapter"
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestHandler(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := slog.New(slogadapter.NewHandler(b, &slog.HandlerOptions{Level: slog.LevelDebug}, clilog.WithFormat(clilog.FormatLogfmt)))
+
+	l.Debug("starting", "files", 2)
+	l.With("cmd", "compact").WithGroup("block").With("id", "01F").Warn("skipped", slog.Group("range", "min", 1, "max", 2), slog.Group(""))
+	l.Error("failed", "err", merrors.New(stderrors.New("err1"), stderrors.New("err2")).Err())
+	testutil.Equals(t, "level=debug msg=starting files=2\n"+
+		"level=warn msg=skipped cmd=compact block.id=01F block.range.min=1 block.range.max=2\n"+
+		"level=error msg=failed err.0=err1 err.1=err2\n", b.String())
+
+	b.Reset()
+	l = slog.New(slogadapter.NewHandler(b, nil, clilog.WithColor(clilog.ColorNever)))
+	l.Debug("filtered")
+	l.Info("compacted", "blocks", []string{"a", "b"})
+	testutil.Equals(t, "INFO: compacted: [a, b]\n", b.String())
+}
+
+func TestLogger(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := slogadapter.NewLogger(slog.New(slog.NewTextHandler(b, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})))
+
+	testutil.Ok(t, l.Log("level", clilog.LevelWarn, "msg", "disk almost full", "free", "1GB"))
+	testutil.Ok(t, l.Log("msg", "done"))
+	testutil.Ok(t, l.Log("level", "debug", "msg", "filtered"))
+	testutil.Equals(t, "level=WARN msg=\"disk almost full\" free=1GB\nlevel=INFO msg=done\n", b.String())
+}
@@ -0,0 +1,153 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// StatusMode specifies how Status renders the status line.
+type StatusMode int
+
+const (
+	// StatusAuto redraws status line if the writer is a terminal, otherwise prints it as plain records. This is the default.
+	StatusAuto StatusMode = iota
+	// StatusRedraw always keeps redrawable status line at the bottom.
+	StatusRedraw
+	// StatusPlain prints status as plain records, not more often than status interval.
+	StatusPlain
+)
+
+// WithStatusMode sets how Status renders the status line. StatusAuto is used by default.
+func WithStatusMode(m StatusMode) Option {
+	return func(o *options) {
+		o.statusMode = m
+	}
+}
+
+// WithStatusInterval sets minimal interval between status records printed in StatusPlain mode. Default is 5s.
+func WithStatusInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.statusInterval = d
+	}
+}
+
+// clearLine moves cursor to the beginning of the line and clears it.
+const clearLine = "\r\033[K"
+
+// Status is an io.Writer that keeps a live status line (e.g "processed 120/900 files") below records written to it.
+// Pass it to New to log records above the status line without corrupting it. Loggers writing to Status detect colors
+// and width of the terminal Status writes to.
+//
+// On terminals, status line is redrawn in place on each Set. On other writers status is printed as
+// plain line, not more often than status interval, so logs in files and CI are not flooded.
+type Status struct {
+	w        io.Writer
+	redraw   bool
+	width    int
+	interval time.Duration
+
+	mtx       sync.Mutex
+	buf       []byte
+	status    string
+	drawn     bool
+	printed   bool
+	lastPrint time.Time
+}
+
+// NewStatus returns Status writing to w. Only WithStatusMode and WithStatusInterval options are used.
+func NewStatus(w io.Writer, opts ...Option) *Status {
+	o := options{statusInterval: 5 * time.Second}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s := &Status{w: w, interval: o.statusInterval}
+	f, tty := terminal(w)
+	switch o.statusMode {
+	case StatusRedraw:
+		s.redraw = true
+	case StatusAuto:
+		s.redraw = tty
+	}
+	if s.redraw && tty {
+		s.width = terminalWidth(f)
+	}
+	return s
+}
+
+// Write writes p (usually a log record) above the status line. Each Write produces no more than one call to w.Write.
+func (s *Status) Write(p []byte) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.drawn {
+		return s.w.Write(p)
+	}
+
+	s.buf = append(s.buf[:0], clearLine...)
+	s.buf = append(s.buf, p...)
+	s.buf = append(s.buf, s.status...)
+	if _, err := s.w.Write(s.buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Set updates the status line. Only the first line of status is used.
+func (s *Status) Set(status string) error {
+	if i := strings.IndexByte(status, '\n'); i >= 0 {
+		status = status[:i]
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.status = status
+	if !s.redraw {
+		s.printed = false
+		if now := time.Now(); s.lastPrint.IsZero() || now.Sub(s.lastPrint) >= s.interval {
+			s.lastPrint = now
+			return s.print()
+		}
+		return nil
+	}
+
+	if s.width > 0 && utf8.RuneCountInString(status) >= s.width {
+		// Status longer than the terminal would wrap and could not be cleared with one clearLine.
+		s.status = string([]rune(status)[:s.width-1])
+	}
+	s.buf = append(s.buf[:0], clearLine...)
+	s.buf = append(s.buf, s.status...)
+	_, err := s.w.Write(s.buf)
+	s.drawn = err == nil
+	return err
+}
+
+func (s *Status) print() error {
+	s.printed = true
+	s.buf = append(append(s.buf[:0], s.status...), '\n')
+	_, err := s.w.Write(s.buf)
+	return err
+}
+
+// Close leaves the last status as a regular line, so it is visible after the program finishes. Status should not be used after Close.
+func (s *Status) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.drawn {
+		s.drawn = false
+		_, err := s.w.Write([]byte("\n"))
+		return err
+	}
+	if !s.redraw && !s.printed && s.status != "" {
+		return s.print()
+	}
+	return nil
+}
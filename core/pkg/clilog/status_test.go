@@ -0,0 +1,50 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package clilog_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/clilog"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestStatus_Redraw(t *testing.T) {
+	b := &bytes.Buffer{}
+	s := clilog.NewStatus(b, clilog.WithStatusMode(clilog.StatusRedraw))
+	l := clilog.New(s)
+
+	testutil.Ok(t, l.Log("msg", "starting"))
+	testutil.Ok(t, s.Set("processed 1/3 files"))
+	testutil.Ok(t, s.Set("processed 2/3 files\nignored"))
+	testutil.Ok(t, l.Log("level", "warn", "msg", "file skipped", "file", "c.txt"))
+	testutil.Ok(t, s.Set("processed 3/3 files"))
+	testutil.Ok(t, s.Close())
+
+	testutil.Equals(t, "starting\n"+
+		"\r\033[Kprocessed 1/3 files"+
+		"\r\033[Kprocessed 2/3 files"+
+		"\r\033[KWARN: file skipped: c.txt\nprocessed 2/3 files"+
+		"\r\033[Kprocessed 3/3 files\n", b.String())
+}
+
+func TestStatus_Plain(t *testing.T) {
+	b := &bytes.Buffer{}
+	s := clilog.NewStatus(b, clilog.WithStatusInterval(time.Hour))
+	l := clilog.New(s)
+
+	testutil.Ok(t, l.Log("msg", "starting"))
+	testutil.Ok(t, s.Set("processed 1/3 files"))
+	testutil.Ok(t, s.Set("processed 2/3 files"))
+	testutil.Ok(t, l.Log("msg", "file skipped", "file", "c.txt"))
+	testutil.Ok(t, s.Set("processed 3/3 files"))
+	testutil.Ok(t, s.Close())
+
+	testutil.Equals(t, "starting\n"+
+		"processed 1/3 files\n"+
+		"file skipped: c.txt\n"+
+		"processed 3/3 files\n", b.String())
+}
@@ -0,0 +1,14 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package clilog
+
+import "os"
+
+// terminalWidth returns zero as terminal width detection is not supported on this platform. Use WithWidth option instead.
+func terminalWidth(_ *os.File) int {
+	return 0
+}
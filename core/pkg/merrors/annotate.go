@@ -0,0 +1,130 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	stderrors "errors"
+	"sort"
+	"strings"
+)
+
+// annotatedError is an error with key/value labels attached.
+type annotatedError struct {
+	err    error
+	labels map[string]string
+}
+
+func (e annotatedError) Error() string {
+	return e.err.Error()
+}
+
+func (e annotatedError) Unwrap() error {
+	return e.err
+}
+
+// Annotate returns error with key/value labels attached (e.g "op", "upload", "shard", "3"), so it's known where the error
+// came from after multi errors are merged. Error message is not changed. If err is a multi error, each of its errors is
+// annotated. Annotating already annotated error overrides labels with the same key. It returns nil if err is nil.
+func Annotate(err error, keyvals ...string) error {
+	if err == nil {
+		return nil
+	}
+	if len(keyvals)%2 == 1 {
+		keyvals = append(keyvals, "")
+	}
+
+	if merr, ok := err.(multiError); ok {
+		e := multiError{errs: make([]error, 0, len(merr.errs))}
+		for _, err := range merr.errs {
+			e.errs = append(e.errs, Annotate(err, keyvals...))
+		}
+		return e
+	}
+
+	a := annotatedError{err: err, labels: make(map[string]string, len(keyvals)/2)}
+	if inner, ok := err.(annotatedError); ok {
+		a.err = inner.err
+		for k, v := range inner.labels {
+			a.labels[k] = v
+		}
+	}
+	for i := 0; i < len(keyvals); i += 2 {
+		a.labels[keyvals[i]] = keyvals[i+1]
+	}
+	return a
+}
+
+// Labels returns all labels attached to the error chain with Annotate. Labels attached closer to the top of the chain
+// take precedence. It returns nil if there are no labels.
+func Labels(err error) map[string]string {
+	var labels map[string]string
+	for ; err != nil; err = stderrors.Unwrap(err) {
+		var l map[string]string
+		switch e := err.(type) {
+		case annotatedError:
+			l = e.labels
+		case *ErrorTree:
+			l = e.Labels
+		}
+		for k, v := range l {
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			if _, ok := labels[k]; !ok {
+				labels[k] = v
+			}
+		}
+	}
+	return labels
+}
+
+// FilterByLabel returns Error with only those errors which have the given label value, or nil if there are none.
+func FilterByLabel(merr Error, key, value string) Error {
+	return Filter(merr, func(err error) bool {
+		v, ok := Labels(err)[key]
+		return ok && v == value
+	})
+}
+
+// GroupByLabel returns errors grouped by value of the given label. Errors without such label are grouped under empty value.
+func GroupByLabel(merr Error, key string) map[string]Error {
+	groups := map[string]multiError{}
+	for _, err := range merr.Errors() {
+		v := Labels(err)[key]
+		g := groups[v]
+		g.errs = append(g.errs, err)
+		groups[v] = g
+	}
+
+	ret := make(map[string]Error, len(groups))
+	for v, g := range groups {
+		ret[v] = g
+	}
+	return ret
+}
+
+// formatLabels returns labels in " [k1=v1 k2=v2]" format, sorted by key, or empty string if there are none.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(" [")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+	}
+	b.WriteByte(']')
+	return b.String()
+}
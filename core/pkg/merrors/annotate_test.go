@@ -0,0 +1,79 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestAnnotate(t *testing.T) {
+	errRefused := stderrors.New("connection refused")
+
+	testutil.Ok(t, merrors.Annotate(nil, "shard", "1"))
+
+	err := merrors.Annotate(errRefused, "shard", "3", "op", "upload")
+	testutil.Equals(t, "connection refused", err.Error())
+	testutil.Assert(t, stderrors.Is(err, errRefused))
+	testutil.Equals(t, map[string]string{"shard": "3", "op": "upload"}, merrors.Labels(err))
+
+	// Re-annotating overrides labels with the same key.
+	err = merrors.Annotate(err, "shard", "4")
+	testutil.Equals(t, map[string]string{"shard": "4", "op": "upload"}, merrors.Labels(err))
+
+	// Labels closer to the top of the chain take precedence.
+	wrapped := merrors.Annotate(fmt.Errorf("sync: %w", err), "shard", "5")
+	testutil.Equals(t, map[string]string{"shard": "5", "op": "upload"}, merrors.Labels(wrapped))
+	testutil.Assert(t, merrors.Labels(errRefused) == nil)
+
+	// Annotating multi error annotates each of its errors.
+	merr := merrors.Annotate(merrors.New(errRefused, stderrors.New("not found")).Err(), "op", "download")
+	for _, err := range merr.(merrors.Error).Errors() {
+		testutil.Equals(t, map[string]string{"op": "download"}, merrors.Labels(err))
+	}
+}
+
+func TestFilterAndGroupByLabel(t *testing.T) {
+	merr := merrors.New(
+		merrors.Annotate(stderrors.New("a"), "shard", "1"),
+		merrors.Annotate(stderrors.New("b"), "shard", "2"),
+		merrors.Annotate(stderrors.New("c"), "shard", "1"),
+		stderrors.New("d"),
+	).Err()
+
+	testutil.Equals(t, "2 errors: a; c", merrors.FilterByLabel(merr, "shard", "1").Error())
+	testutil.Assert(t, merrors.FilterByLabel(merr, "shard", "3") == nil)
+
+	groups := merrors.GroupByLabel(merr, "shard")
+	testutil.Equals(t, 3, len(groups))
+	testutil.Equals(t, "2 errors: a; c", groups["1"].Error())
+	testutil.Equals(t, "b", groups["2"].Errors()[0].Error())
+	testutil.Equals(t, "d", groups[""].Errors()[0].Error())
+}
+
+func TestAnnotate_PrettyPrintAndJSON(t *testing.T) {
+	merr := merrors.New(
+		merrors.Annotate(stderrors.New("connection refused"), "shard", "3", "op", "upload"),
+		stderrors.New("not found"),
+	).Err()
+
+	b := &bytes.Buffer{}
+	testutil.Ok(t, merrors.PrettyPrint(b, merr))
+	testutil.Equals(t, "2 errors:\n\tconnection refused [op=upload shard=3]\n\tnot found", b.String())
+
+	j, err := json.Marshal(merr)
+	testutil.Ok(t, err)
+
+	decoded, err := merrors.DecodeJSON(j)
+	testutil.Ok(t, err)
+	testutil.Equals(t, merr.Error(), decoded.Error())
+	testutil.Equals(t, map[string]string{"shard": "3", "op": "upload"}, merrors.Labels(decoded.Errors[0]))
+	testutil.Assert(t, merrors.Labels(decoded.Errors[1]) == nil)
+}
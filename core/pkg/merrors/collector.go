@@ -0,0 +1,83 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Collector is a goroutine-safe version of NilOrMultiError, useful for collecting errors from many goroutines
+// (e.g worker pools). Zero value is ready to use and keeps all errors.
+type Collector struct {
+	mtx     sync.Mutex
+	merr    NilOrMultiError
+	limit   int
+	dropped int
+}
+
+// NewCollector returns Collector that keeps at most limit errors. Errors added after the limit is reached are only
+// counted. Zero or negative limit means no limit.
+func NewCollector(limit int) *Collector {
+	return &Collector{limit: limit}
+}
+
+// Add adds single or many errors to the error list in the same way as NilOrMultiError.Add does. It is safe to be called
+// concurrently.
+func (c *Collector) Add(errs ...error) {
+	var added NilOrMultiError
+	added.Add(errs...)
+	if len(added.errs) == 0 {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.limit <= 0 {
+		c.merr.errs = append(c.merr.errs, added.errs...)
+		return
+	}
+	n := c.limit - len(c.merr.errs)
+	if n > len(added.errs) {
+		n = len(added.errs)
+	}
+	if n < 0 {
+		n = 0
+	}
+	c.merr.errs = append(c.merr.errs, added.errs[:n]...)
+	c.dropped += len(added.errs) - n
+}
+
+// Dropped returns the number of errors that were not kept due to the limit.
+func (c *Collector) Dropped() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.dropped
+}
+
+// Err returns the error list as an Error (also implements error) or nil if no error was added. If any errors were dropped,
+// the last error informs how many. Returned Error is not affected by further Add calls.
+func (c *Collector) Err() Error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if len(c.merr.errs) == 0 {
+		return nil
+	}
+	errs := make([]error, len(c.merr.errs), len(c.merr.errs)+1)
+	copy(errs, c.merr.errs)
+	if c.dropped > 0 {
+		errs = append(errs, droppedErrors(c.dropped))
+	}
+	return multiError{errs: errs}
+}
+
+// droppedErrors represents number of errors that were dropped by Collector.
+type droppedErrors int
+
+func (d droppedErrors) Error() string {
+	return strconv.Itoa(int(d)) + " more errors dropped"
+}
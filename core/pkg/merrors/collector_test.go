@@ -0,0 +1,50 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	stderrors "errors"
+	"sync"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := &merrors.Collector{}
+	testutil.Ok(t, c.Err())
+
+	err := stderrors.New("err")
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Add(err, nil)
+		}()
+	}
+	wg.Wait()
+
+	merr := c.Err()
+	testutil.NotOk(t, merr)
+	testutil.Equals(t, 100, merr.Count(err))
+	testutil.Equals(t, 0, c.Dropped())
+
+	// Returned error is not affected by further adds.
+	c.Add(err)
+	testutil.Equals(t, 100, len(merr.Errors()))
+}
+
+func TestCollector_Limit(t *testing.T) {
+	c := merrors.NewCollector(3)
+	err1, err2 := stderrors.New("err1"), stderrors.New("err2")
+
+	c.Add(err1, nil, err1)
+	c.Add(merrors.New(err2, err2).Err())
+	c.Add(err1)
+	testutil.Equals(t, 2, c.Dropped())
+	testutil.Equals(t, "4 errors: err1; err1; err2; 2 more errors dropped", c.Err().Error())
+	testutil.Equals(t, 2, c.Err().Count(err1))
+}
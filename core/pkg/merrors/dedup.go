@@ -0,0 +1,103 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	stderrors "errors"
+	"strconv"
+)
+
+// repeatedError represents error that occurred multiple times.
+type repeatedError struct {
+	err error
+	n   int
+}
+
+func (e repeatedError) Error() string {
+	return e.err.Error() + " (x" + strconv.Itoa(e.n) + ")"
+}
+
+func (e repeatedError) Unwrap() error {
+	return e.err
+}
+
+// Occurrences returns the number of times given error occurred. It's more than one only for errors collapsed by Dedup
+// (including wrapped ones, e.g with stack recorded by NewWithStacks).
+func Occurrences(err error) int {
+	var r repeatedError
+	if stderrors.As(err, &r) {
+		return r.n
+	}
+	return 1
+}
+
+// Dedup returns Error with errors that have identical message collapsed into a single error (at the position of the
+// first occurrence) with the number of occurrences, e.g "connection refused (x487)". Errors with different messages are
+// collapsed only by DedupMatching, so no message is lost unless asked for. Nested multi errors are treated as any other
+// error. Occurrences can be checked with Occurrences function and are respected by Count. It returns nil if merr is nil.
+//
+// Use it before printing to make errors with many repeated causes readable, e.g:
+//
+//	merrors.PrettyPrint(w, merrors.Dedup(merr))
+func Dedup(merr Error) Error {
+	return DedupMatching(merr)
+}
+
+// DedupMatching works like Dedup, but also collapses errors matching the same target, as defined by errors.Is, even if
+// their messages differ, e.g errors wrapping io.EOF with different context. Collapsed error keeps the message of
+// the first occurrence, so messages of the others are lost. It returns nil if merr is nil.
+func DedupMatching(merr Error, targets ...error) Error {
+	if merr == nil {
+		return nil
+	}
+
+	var groups []repeatedError
+	// targetGroup maps index of the target to index of the group of errors matching it.
+	targetGroup := map[int]int{}
+	for _, err := range merr.Errors() {
+		n := 1
+		var r repeatedError
+		if stderrors.As(err, &r) {
+			n, err = r.n, r.err
+		}
+
+		target := -1
+		for i, t := range targets {
+			if stderrors.Is(err, t) {
+				target = i
+				break
+			}
+		}
+		if g, ok := targetGroup[target]; ok && target >= 0 {
+			groups[g].n += n
+			continue
+		}
+
+		found := false
+		for i := range groups {
+			if groups[i].err.Error() == err.Error() {
+				groups[i].n += n
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		if target >= 0 {
+			targetGroup[target] = len(groups)
+		}
+		groups = append(groups, repeatedError{err: err, n: n})
+	}
+
+	e := multiError{errs: make([]error, 0, len(groups))}
+	for _, g := range groups {
+		if g.n == 1 {
+			e.errs = append(e.errs, g.err)
+			continue
+		}
+		e.errs = append(e.errs, g)
+	}
+	return e
+}
@@ -0,0 +1,79 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestDedup(t *testing.T) {
+	errRefused := stderrors.New("connection refused")
+
+	merr := merrors.New()
+	for i := 0; i < 487; i++ {
+		merr.Add(fmt.Errorf("upload: %w", errRefused))
+	}
+	merr.Add(stderrors.New("not found"))
+	for i := 0; i < 10; i++ {
+		// Same message, different error instances.
+		merr.Add(stderrors.New("timeout"))
+	}
+	merr.Add(errRefused)
+
+	deduped := merrors.Dedup(merr.Err())
+	// Equivalent errors with different messages are not collapsed.
+	testutil.Equals(t, "4 errors: upload: connection refused (x487); not found; timeout (x10); connection refused", deduped.Error())
+	testutil.Equals(t, 488, deduped.Count(errRefused))
+	testutil.Assert(t, stderrors.Is(deduped, errRefused))
+	testutil.Equals(t, 487, merrors.Occurrences(deduped.Errors()[0]))
+	testutil.Equals(t, 1, merrors.Occurrences(deduped.Errors()[1]))
+
+	// Dedup is idempotent.
+	testutil.Equals(t, deduped.Error(), merrors.Dedup(deduped).Error())
+
+	b := &bytes.Buffer{}
+	testutil.Ok(t, merrors.PrettyPrint(b, deduped))
+	testutil.Equals(t, "4 errors:\n\tupload: connection refused (x487)\n\tnot found\n\ttimeout (x10)\n\tconnection refused", b.String())
+}
+
+func TestDedup_DifferentMessages(t *testing.T) {
+	deduped := merrors.Dedup(merrors.New(fmt.Errorf("read x: %w", io.EOF), io.EOF).Err())
+	testutil.Equals(t, "2 errors: read x: EOF; EOF", deduped.Error())
+	testutil.Equals(t, 2, deduped.Count(io.EOF))
+}
+
+func TestDedupMatching(t *testing.T) {
+	errRefused := stderrors.New("connection refused")
+	merr := merrors.New(
+		fmt.Errorf("upload a: %w", errRefused),
+		io.EOF,
+		fmt.Errorf("upload b: %w", errRefused),
+		fmt.Errorf("read x: %w", io.EOF),
+		stderrors.New("not found"),
+		stderrors.New("not found"),
+	).Err()
+
+	deduped := merrors.DedupMatching(merr, errRefused)
+	testutil.Equals(t, "4 errors: upload a: connection refused (x2); EOF; read x: EOF; not found (x2)", deduped.Error())
+	testutil.Equals(t, 2, deduped.Count(errRefused))
+
+	deduped = merrors.DedupMatching(merr, errRefused, io.EOF)
+	testutil.Equals(t, "3 errors: upload a: connection refused (x2); EOF (x2); not found (x2)", deduped.Error())
+	testutil.Equals(t, 2, deduped.Count(io.EOF))
+
+	// Errors collapsed earlier are merged with counts.
+	testutil.Equals(t, deduped.Error(), merrors.DedupMatching(merrors.Dedup(merr), errRefused, io.EOF).Error())
+}
+
+func TestDedup_Nil(t *testing.T) {
+	testutil.Assert(t, merrors.Dedup(nil) == nil)
+	testutil.Assert(t, merrors.DedupMatching(nil, io.EOF) == nil)
+}
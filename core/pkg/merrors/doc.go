@@ -4,6 +4,8 @@
 package merrors
 
 // Safe multi error implementation that chains errors on the same level. Supports errors.As and errors.Is functions.
+// Interoperates with Go 1.20+ multi errors: it implements Unwrap() []error, and errors created with errors.Join are
+// flattened by Add and treated as multi errors by AsMulti, Count and PrettyPrint.
 //
 // Example 1:
 //
@@ -18,3 +20,51 @@ package merrors
 //  }
 //  return merr.Err()
 //
+// Example 3 (concurrent):
+//
+//  merr := merrors.NewCollector(100) // Keep at most 100 errors, count the rest.
+//  for _, job := range jobs {
+//    go func(job Job) { merr.Add(job.Do()) }(job)
+//  }
+//  ...
+//  return merr.Err()
+//
+// Example 4 (errgroup-like, all errors are returned in submission order):
+//
+//  g, ctx := merrors.NewGroup(ctx, merrors.WithGroupLimit(10))
+//  for _, job := range jobs {
+//    job := job
+//    g.Go(func() error { return job.Do(ctx) })
+//  }
+//  return g.Wait()
+//
+// Errors repeated many times can be collapsed for printing with Dedup, e.g "connection refused (x487)". Use DedupMatching
+// to also collapse errors wrapping the same cause with different messages:
+//
+//  merrors.PrettyPrint(os.Stderr, merrors.DedupMatching(merr, syscall.ECONNREFUSED))
+//
+// Multi errors marshal to JSON as ErrorTree, keeping nesting, wrapped causes and Go type names. Use DecodeJSON to rebuild the tree
+// for inspection.
+//
+// Errors can be annotated with labels telling where they came from, which are kept when merging and shown by PrettyPrint:
+//
+//  merr.Add(merrors.Annotate(err, "shard", strconv.Itoa(i)))
+//  ...
+//  shard3 := merrors.FilterByLabel(merr.Err(), "shard", "3")
+//
+// Errors can be filtered, partitioned, mapped and flattened, each returning new Error or nil if no errors are left:
+//
+//  retryable, fatal := merrors.Partition(merrors.Without(merr.Err(), context.Canceled), merrors.Matching(errRetryable))
+//
+// To tell where each error was added (e.g by which goroutine), use NewWithStacks and print recorded stacks:
+//
+//  merr := merrors.NewWithStacks()
+//  ...
+//  merrors.PrettyPrint(os.Stderr, merr.Err(), merrors.WithStacks())
+//
+// Stacks are also available through Stacks function. Errors added with stacks are wrapped, so use errors.As to check their type.
+//
+// PrettyPrint output for big failures can be shaped with options, e.g:
+//
+//  merrors.PrettyPrint(os.Stderr, merr.Err(), merrors.WithTreeGlyphs(), merrors.WithMaxChildren(10), merrors.WithMaxLength(200))
+//
@@ -7,12 +7,13 @@ import (
 	"bytes"
 	stderrors "errors"
 	"fmt"
-	"io"
 )
 
 // NilOrMultiError type allows combining multiple errors into one.
 type NilOrMultiError struct {
 	errs []error
+	// stacks is true if stack of the Add caller is recorded for each added error.
+	stacks bool
 }
 
 // New returns NilOrMultiError with provided errors added if not nil.
@@ -23,17 +24,33 @@ func New(errs ...error) *NilOrMultiError {
 }
 
 // Add adds single or many errors to the error list. Each error is added only if not nil.
-// If the error is a multiError type, the errors inside multiError are added to the main NilOrMultiError.
+// If the error is a multiError type or any other error implementing Unwrap() []error (e.g created by errors.Join),
+// the errors it wraps are added to the main NilOrMultiError.
+// If NilOrMultiError was created with NewWithStacks, the stack of Add caller is recorded for each added error.
 func (e *NilOrMultiError) Add(errs ...error) {
+	var stack []uintptr
+	if e.stacks {
+		stack = callers()
+	}
+	e.add(stack, errs)
+}
+
+func (e *NilOrMultiError) add(stack []uintptr, errs []error) {
 	for _, err := range errs {
 		if err == nil {
 			continue
 		}
 		if merr, ok := err.(multiError); ok {
-			e.errs = append(e.errs, merr.errs...)
+			for _, err := range merr.errs {
+				e.errs = append(e.errs, withStack(err, stack))
+			}
 			continue
 		}
-		e.errs = append(e.errs, err)
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			e.add(stack, joined.Unwrap())
+			continue
+		}
+		e.errs = append(e.errs, withStack(err, stack))
 	}
 }
 
@@ -42,7 +59,7 @@ func (e NilOrMultiError) Err() Error {
 	if len(e.errs) == 0 {
 		return nil
 	}
-	return multiError(e)
+	return multiError{errs: e.errs}
 }
 
 // Error is extended error interface that allows to use returned read-only multi error in more advanced ways.
@@ -82,6 +99,11 @@ func (e multiError) Errors() []error {
 	return e.errs
 }
 
+// Unwrap returns underlying errors. It allows errors.Is and errors.As from Go 1.20+ to inspect them.
+func (e multiError) Unwrap() []error {
+	return e.errs
+}
+
 // Error returns a concatenated string of the contained errors.
 func (e multiError) Error() string {
 	var buf bytes.Buffer
@@ -146,7 +168,7 @@ func (e multiError) Is(target error) bool {
 }
 
 // Count returns the number of all multi error' errors that match the given target (including nested multi errors).
-// Matching is defined as in Is method.
+// Matching is defined as in Is method. Errors collapsed by Dedup are counted as many times as they occurred.
 func (e multiError) Count(target error) (count int) {
 	for _, err := range e.errs {
 		if inner, ok := AsMulti(err); ok {
@@ -155,24 +177,41 @@ func (e multiError) Count(target error) (count int) {
 		}
 
 		if stderrors.Is(err, target) {
-			count++
+			count += Occurrences(err)
 		}
 	}
 	return count
 }
 
-// AsMulti casts error to multi error read only interface. It returns multi error and true if error matches multi error as
-// defined by As method. If returns false if no multi error can be found.
+// AsMulti casts error to multi error read only interface. It returns multi error and true if error or any error in its
+// chain (followed with Unwrap() error) is a multi error or implements Unwrap() []error (e.g created by errors.Join).
+// The first such error is returned as a whole, so errors joined with a multi error are not lost.
+// If returns false if no multi error can be found.
 func AsMulti(err error) (Error, bool) {
-	m := multiError{}
-	if !stderrors.As(err, &m) {
-		return nil, false
+	for ; err != nil; err = stderrors.Unwrap(err) {
+		if m, ok := err.(multiError); ok {
+			return m, true
+		}
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			continue
+		}
+		m := multiError{}
+		for _, err := range joined.Unwrap() {
+			if err != nil {
+				m.errs = append(m.errs, err)
+			}
+		}
+		if len(m.errs) == 0 {
+			return nil, false
+		}
+		return m, true
 	}
-	return m, true
+	return nil, false
 }
 
 // Merge merges multiple Error to single one, but joining all errors together.
-// NOTE: Nested multi errors are not merged.
+// NOTE: Nested multi errors are not merged, use Flatten on the result to do so.
 func Merge(errs []Error) Error {
 	e := multiError{}
 	for _, err := range errs {
@@ -180,36 +219,3 @@ func Merge(errs []Error) Error {
 	}
 	return e
 }
-
-// PrettyPrint prints the same information as multiError.Error() method but with newlines and indentation targeted
-// for humans.
-func PrettyPrint(w io.Writer, err Error) error {
-	return prettyPrint(w, "\t", err)
-}
-
-func prettyPrint(w io.Writer, indent string, merr Error) error {
-	if len(merr.Errors()) > 1 {
-		if _, err := fmt.Fprintf(w, "%d errors:\n", len(merr.Errors())); err != nil {
-			return err
-		}
-	}
-	for i, err := range merr.Errors() {
-		if i != 0 {
-			if _, err := w.Write([]byte("\n")); err != nil {
-				return err
-			}
-		}
-
-		if merr2, ok := AsMulti(err); ok {
-			if err := prettyPrint(w, indent+"\t", merr2); err != nil {
-				return nil
-			}
-			continue
-		}
-
-		if _, err := w.Write([]byte(indent + err.Error())); err != nil {
-			return err
-		}
-	}
-	return nil
-}
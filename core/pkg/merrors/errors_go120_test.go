@@ -0,0 +1,54 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+//go:build go1.20
+// +build go1.20
+
+package merrors_test
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestMultiError_Join(t *testing.T) {
+	err1 := stderrors.New("err1")
+	err2 := customErr{error: stderrors.New("err2")}
+	err3 := stderrors.New("err3")
+
+	// Joined errors are flattened by Add.
+	merr := merrors.New(stderrors.Join(err1, err2), nil, stderrors.Join(stderrors.Join(err3))).Err()
+	testutil.Equals(t, []error{err1, err2, err3}, merr.Errors())
+
+	// Multi errors are inspectable by standard library, also when wrapped and joined.
+	wrapped := fmt.Errorf("wrap: %w", merrors.New(err1, err2).Err())
+	testutil.Assert(t, stderrors.Is(wrapped, err1))
+	testutil.Assert(t, stderrors.As(wrapped, &customErr{}))
+	testutil.Assert(t, stderrors.Is(stderrors.Join(err3, wrapped), err1))
+
+	// Joined errors are treated as multi errors.
+	joined := fmt.Errorf("wrap: %w", stderrors.Join(err1, stderrors.Join(err1, err3)))
+	m, ok := merrors.AsMulti(joined)
+	testutil.Assert(t, ok)
+	testutil.Equals(t, 2, m.Count(err1))
+	testutil.Equals(t, 1, m.Count(err3))
+
+	b := &bytes.Buffer{}
+	testutil.Ok(t, merrors.PrettyPrint(b, m))
+	testutil.Equals(t, "2 errors:\n\terr1\n\t2 errors:\n\t\terr1\n\t\terr3", b.String())
+
+	// Multi error joined with other errors does not hide them.
+	m, ok = merrors.AsMulti(stderrors.Join(err3, merrors.New(err1, err1).Err()))
+	testutil.Assert(t, ok)
+	testutil.Equals(t, "2 errors: err3; 2 errors: err1; err1", m.Error())
+	testutil.Equals(t, 2, m.Count(err1))
+	testutil.Equals(t, 1, m.Count(err3))
+
+	_, ok = merrors.AsMulti(fmt.Errorf("wrap: %w", err1))
+	testutil.Assert(t, !ok)
+}
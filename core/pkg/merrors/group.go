@@ -0,0 +1,101 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	"context"
+	"sync"
+)
+
+// GroupOption configures Group.
+type GroupOption func(*Group)
+
+// WithGroupLimit limits the number of functions running at the same time. Go blocks until function can be started.
+// Zero or negative limit means no limit.
+func WithGroupLimit(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithCancelOnError makes Group cancel its context on the first error returned by any function.
+func WithCancelOnError() GroupOption {
+	return func(g *Group) {
+		g.cancelOnError = true
+	}
+}
+
+// Group runs functions in goroutines and collects all errors they return, similar to golang.org/x/sync/errgroup,
+// but without losing any error. Zero value is ready to use, has no limit and does not cancel on error.
+type Group struct {
+	cancel        context.CancelFunc
+	cancelOnError bool
+	sem           chan struct{}
+
+	wg   sync.WaitGroup
+	mtx  sync.Mutex
+	errs []error
+}
+
+// NewGroup returns a new Group and a derived context. The derived context is canceled when Wait returns or
+// (with WithCancelOnError option) when any function returns an error.
+func NewGroup(ctx context.Context, opts ...GroupOption) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{cancel: cancel}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, ctx
+}
+
+// Go runs the given function in a new goroutine. If limit was set, it blocks until function can be started.
+func (g *Group) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	g.mtx.Lock()
+	i := len(g.errs)
+	g.errs = append(g.errs, nil)
+	g.mtx.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+		}()
+
+		err := f()
+		if err == nil {
+			return
+		}
+
+		g.mtx.Lock()
+		g.errs[i] = err
+		g.mtx.Unlock()
+
+		if g.cancelOnError && g.cancel != nil {
+			g.cancel()
+		}
+	}()
+}
+
+// Wait blocks until all functions have returned. It returns all errors in the order functions were passed to Go,
+// or nil if none failed.
+func (g *Group) Wait() Error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel()
+	}
+
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	return New(g.errs...).Err()
+}
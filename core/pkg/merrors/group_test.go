@@ -0,0 +1,72 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func TestGroup(t *testing.T) {
+	defer testutil.TolerantVerifyLeak(t)
+
+	g, ctx := merrors.NewGroup(context.Background(), merrors.WithGroupLimit(2))
+
+	var running, maxRunning int32
+	for i := 0; i < 10; i++ {
+		i := i
+		g.Go(func() error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+
+			// Finish in reverse order to check errors are in submission order.
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			if i%3 == 0 {
+				return fmt.Errorf("job %d failed", i)
+			}
+			return nil
+		})
+	}
+
+	err := g.Wait()
+	testutil.NotOk(t, err)
+	testutil.Equals(t, "4 errors: job 0 failed; job 3 failed; job 6 failed; job 9 failed", err.Error())
+	testutil.Assert(t, atomic.LoadInt32(&maxRunning) <= 2)
+	testutil.NotOk(t, ctx.Err())
+
+	var zero merrors.Group
+	zero.Go(func() error { return nil })
+	testutil.Ok(t, zero.Wait())
+}
+
+func TestGroup_CancelOnError(t *testing.T) {
+	defer testutil.TolerantVerifyLeak(t)
+
+	g, ctx := merrors.NewGroup(context.Background(), merrors.WithCancelOnError())
+	errFailed := stderrors.New("failed")
+
+	g.Go(func() error { return errFailed })
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := g.Wait()
+	testutil.Equals(t, 2, len(err.Errors()))
+	testutil.Assert(t, stderrors.Is(err, errFailed))
+	testutil.Assert(t, stderrors.Is(err, context.Canceled))
+}
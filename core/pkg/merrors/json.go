@@ -0,0 +1,100 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+)
+
+// ErrorTree is a structured representation of an error, including nested multi errors and wrapped causes. It's used for
+// JSON marshaling of multi errors and rebuilding them for post-mortem inspection.
+type ErrorTree struct {
+	// Message is the result of the Error method.
+	Message string `json:"message"`
+	// Type is the Go type name of the error, e.g "*errors.errorString".
+	Type string `json:"type,omitempty"`
+	// Cause is the error wrapped by this error, if any.
+	Cause *ErrorTree `json:"cause,omitempty"`
+	// Errors are errors of the multi error, if this error is one.
+	Errors []*ErrorTree `json:"errors,omitempty"`
+	// Labels are labels attached to the error with Annotate, if any.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NewErrorTree returns ErrorTree for the given error. Multi errors (including errors implementing Unwrap() []error) are
+// represented with Errors, wrapped errors are represented with Cause.
+func NewErrorTree(err error) *ErrorTree {
+	if a, ok := err.(annotatedError); ok {
+		// Annotations are represented as labels of the annotated error.
+		t := NewErrorTree(a.err)
+		if t.Labels == nil {
+			t.Labels = make(map[string]string, len(a.labels))
+		}
+		for k, v := range a.labels {
+			t.Labels[k] = v
+		}
+		return t
+	}
+
+	if s, ok := err.(stackError); ok {
+		// Stacks recorded by NewWithStacks are not represented.
+		return NewErrorTree(s.err)
+	}
+
+	t := &ErrorTree{Message: err.Error(), Type: fmt.Sprintf("%T", err)}
+	if merr, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range merr.Unwrap() {
+			if e != nil {
+				t.Errors = append(t.Errors, NewErrorTree(e))
+			}
+		}
+		return t
+	}
+	if cause := stderrors.Unwrap(err); cause != nil {
+		t.Cause = NewErrorTree(cause)
+	}
+	return t
+}
+
+// DecodeJSON decodes ErrorTree from JSON produced by marshaling multi error or ErrorTree.
+func DecodeJSON(b []byte) (*ErrorTree, error) {
+	t := &ErrorTree{}
+	if err := json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Error returns the error message.
+func (t *ErrorTree) Error() string {
+	return t.Message
+}
+
+// Unwrap returns the cause of the error, if any. Causes that are multi errors are returned as Error.
+func (t *ErrorTree) Unwrap() error {
+	if t.Cause == nil {
+		return nil
+	}
+	return t.Cause.Err()
+}
+
+// Err returns the tree as an error that can be inspected with errors.As, errors.Is, AsMulti etc. Multi error nodes
+// are returned as Error with their Errors, other nodes are returned as is.
+func (t *ErrorTree) Err() error {
+	if len(t.Errors) == 0 {
+		return t
+	}
+	e := multiError{errs: make([]error, 0, len(t.Errors))}
+	for _, c := range t.Errors {
+		e.errs = append(e.errs, c.Err())
+	}
+	return e
+}
+
+// MarshalJSON marshals multi error as ErrorTree, keeping nested errors, wrapped causes and Go type names.
+func (e multiError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(NewErrorTree(e))
+}
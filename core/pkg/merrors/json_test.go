@@ -0,0 +1,53 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func TestMultiError_MarshalJSON(t *testing.T) {
+	merr := merrors.New(
+		stderrors.New("err1"),
+		customErr{error: stderrors.New("err2")},
+		// Wrap it so Add cannot add inner errors in.
+		pkgerrors.WithMessage(merrors.New(stderrors.New("err3"), stderrors.New("err4")).Err(), "wrap"),
+	).Err()
+
+	b, err := json.Marshal(merr)
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"message":"3 errors: err1; err2; wrap: 2 errors: err3; err4","type":"merrors.multiError","errors":[`+
+		`{"message":"err1","type":"*errors.errorString"},`+
+		`{"message":"err2","type":"merrors_test.customErr"},`+
+		`{"message":"wrap: 2 errors: err3; err4","type":"*errors.withMessage","cause":{"message":"2 errors: err3; err4","type":"merrors.multiError","errors":[`+
+		`{"message":"err3","type":"*errors.errorString"},{"message":"err4","type":"*errors.errorString"}]}}]}`, string(b))
+
+	// Wrapped multi errors are marshaled as well.
+	b2, err := json.Marshal(struct{ Err error }{Err: merr})
+	testutil.Ok(t, err)
+	testutil.Equals(t, `{"Err":`+string(b)+`}`, string(b2))
+
+	tree, err := merrors.DecodeJSON(b)
+	testutil.Ok(t, err)
+	testutil.Equals(t, merrors.NewErrorTree(merr), tree)
+
+	decoded, ok := merrors.AsMulti(tree.Err())
+	testutil.Assert(t, ok)
+	testutil.Equals(t, merr.Error(), decoded.Error())
+	testutil.Equals(t, 3, len(decoded.Errors()))
+
+	// Nested multi error is reachable through the cause of the wrapping error.
+	var wrapping *merrors.ErrorTree
+	testutil.Assert(t, stderrors.As(decoded.Errors()[2], &wrapping))
+	testutil.Equals(t, "*errors.withMessage", wrapping.Type)
+	nested, ok := merrors.AsMulti(wrapping)
+	testutil.Assert(t, ok)
+	testutil.Equals(t, "2 errors: err3; err4", nested.Error())
+}
@@ -0,0 +1,101 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	stderrors "errors"
+)
+
+// Filter returns Error with only those errors for which keep returns true, or nil if there are none.
+// Nested multi errors are passed to keep as a whole; use Flatten first to filter their errors individually.
+func Filter(merr Error, keep func(err error) bool) Error {
+	if merr == nil {
+		return nil
+	}
+	e := multiError{}
+	for _, err := range merr.Errors() {
+		if keep(err) {
+			e.errs = append(e.errs, err)
+		}
+	}
+	return e.nilIfEmpty()
+}
+
+// Matching returns predicate for Filter and Partition which is true for errors matching any of the targets,
+// as defined by errors.Is.
+func Matching(targets ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if stderrors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Without returns Error without errors matching any of the targets (e.g context.Canceled), or nil if there are no other errors.
+func Without(merr Error, targets ...error) Error {
+	matching := Matching(targets...)
+	return Filter(merr, func(err error) bool { return !matching(err) })
+}
+
+// Partition splits errors into those for which fn returns true and the rest, e.g retryable and fatal ones.
+// Each of returned Error is nil if it would be empty.
+func Partition(merr Error, fn func(err error) bool) (matched Error, rest Error) {
+	if merr == nil {
+		return nil, nil
+	}
+	m, r := multiError{}, multiError{}
+	for _, err := range merr.Errors() {
+		if fn(err) {
+			m.errs = append(m.errs, err)
+			continue
+		}
+		r.errs = append(r.errs, err)
+	}
+	return m.nilIfEmpty(), r.nilIfEmpty()
+}
+
+// Map returns Error with each error replaced by fn result, e.g wrapped with additional context. Errors for which
+// fn returns nil are dropped. Multi errors returned by fn are flattened the same way as in NilOrMultiError.Add.
+// It returns nil if no errors are left.
+func Map(merr Error, fn func(err error) error) Error {
+	if merr == nil {
+		return nil
+	}
+	e := &NilOrMultiError{}
+	for _, err := range merr.Errors() {
+		e.Add(fn(err))
+	}
+	return e.Err()
+}
+
+// Flatten returns Error with all nested multi errors (including those created by errors.Join) replaced by errors they
+// contain, recursively. Messages of errors wrapping nested multi errors are not kept. It returns nil if merr is nil.
+func Flatten(merr Error) Error {
+	if merr == nil {
+		return nil
+	}
+	e := multiError{}
+	flatten(&e, merr)
+	return e.nilIfEmpty()
+}
+
+func flatten(dst *multiError, merr Error) {
+	for _, err := range merr.Errors() {
+		if inner, ok := AsMulti(err); ok {
+			flatten(dst, inner)
+			continue
+		}
+		dst.errs = append(dst.errs, err)
+	}
+}
+
+func (e multiError) nilIfEmpty() Error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,87 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+var errRetryable = stderrors.New("retryable")
+
+func TestFilter(t *testing.T) {
+	merr := merrors.New(
+		fmt.Errorf("upload: %w", errRetryable),
+		stderrors.New("fatal"),
+		context.Canceled,
+	).Err()
+
+	testutil.Equals(t, "upload: retryable", merrors.Filter(merr, merrors.Matching(errRetryable)).Error())
+	testutil.Equals(t, "2 errors: upload: retryable; fatal", merrors.Without(merr, context.Canceled).Error())
+	testutil.Equals(t, "fatal", merrors.Without(merr, context.Canceled, errRetryable).Error())
+	testutil.Assert(t, merrors.Filter(merr, merrors.Matching(context.DeadlineExceeded)) == nil)
+	testutil.Assert(t, merrors.Without(merrors.New(context.Canceled).Err(), context.Canceled) == nil)
+	testutil.Assert(t, merrors.Filter(nil, merrors.Matching(errRetryable)) == nil)
+}
+
+func TestPartition(t *testing.T) {
+	merr := merrors.New(
+		fmt.Errorf("upload: %w", errRetryable),
+		stderrors.New("fatal"),
+		errRetryable,
+	).Err()
+
+	retryable, fatal := merrors.Partition(merr, merrors.Matching(errRetryable))
+	testutil.Equals(t, "2 errors: upload: retryable; retryable", retryable.Error())
+	testutil.Equals(t, "fatal", fatal.Error())
+
+	retryable, fatal = merrors.Partition(merrors.New(errRetryable).Err(), merrors.Matching(errRetryable))
+	testutil.Equals(t, "retryable", retryable.Error())
+	testutil.Assert(t, fatal == nil)
+}
+
+func TestMap(t *testing.T) {
+	merr := merrors.New(stderrors.New("a"), context.Canceled, stderrors.New("b")).Err()
+
+	wrapped := merrors.Map(merr, func(err error) error {
+		if stderrors.Is(err, context.Canceled) {
+			return nil
+		}
+		return fmt.Errorf("shard 1: %w", err)
+	})
+	testutil.Equals(t, "2 errors: shard 1: a; shard 1: b", wrapped.Error())
+
+	// Multi errors returned by fn are flattened.
+	split := merrors.Map(merr, func(err error) error {
+		return merrors.New(err, err).Err()
+	})
+	testutil.Equals(t, 6, len(split.Errors()))
+
+	testutil.Assert(t, merrors.Map(merr, func(error) error { return nil }) == nil)
+}
+
+func TestFlatten(t *testing.T) {
+	errA, errB, errC := stderrors.New("a"), stderrors.New("b"), stderrors.New("c")
+
+	merr := merrors.New(
+		errA,
+		fmt.Errorf("wrap: %w", merrors.New(errB, fmt.Errorf("wrap: %w", merrors.New(errC, errA).Err())).Err()),
+	).Err()
+	testutil.Equals(t, 2, len(merr.Errors()))
+
+	flat := merrors.Flatten(merr)
+	testutil.Equals(t, "4 errors: a; b; c; a", flat.Error())
+	testutil.Equals(t, 2, flat.Count(errA))
+	testutil.Assert(t, merrors.Flatten(nil) == nil)
+
+	// Merge does not flatten nested multi errors, Flatten does.
+	merged := merrors.Merge([]merrors.Error{merr, merrors.New(errC).Err()})
+	testutil.Equals(t, 3, len(merged.Errors()))
+	testutil.Equals(t, 5, len(merrors.Flatten(merged).Errors()))
+}
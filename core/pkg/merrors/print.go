@@ -0,0 +1,206 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PrintOption configures PrettyPrint.
+type PrintOption func(*printOptions)
+
+type printOptions struct {
+	stacks      bool
+	glyphs      bool
+	maxDepth    int
+	maxChildren int
+	maxLength   int
+	color       bool
+}
+
+// WithStacks makes PrettyPrint print stacks recorded for errors added to NilOrMultiError created with NewWithStacks,
+// below each error, similar to "%+v" formatting of github.com/pkg/errors.
+func WithStacks() PrintOption {
+	return func(o *printOptions) {
+		o.stacks = true
+	}
+}
+
+// WithTreeGlyphs makes PrettyPrint draw nesting with Unicode tree glyphs (├──, └──) instead of tab indentation.
+func WithTreeGlyphs() PrintOption {
+	return func(o *printOptions) {
+		o.glyphs = true
+	}
+}
+
+// WithMaxDepth limits how deep nested multi errors are expanded. Errors on the top level have depth 1. Multi errors
+// on the maximum depth are printed in a single line, as returned by their Error method. Zero (default) means no limit.
+func WithMaxDepth(n int) PrintOption {
+	return func(o *printOptions) {
+		o.maxDepth = n
+	}
+}
+
+// WithMaxChildren limits how many errors of each (nested) multi error are printed. The rest is summarized
+// with "and N more" line. Zero (default) means no limit.
+func WithMaxChildren(n int) PrintOption {
+	return func(o *printOptions) {
+		o.maxChildren = n
+	}
+}
+
+// WithMaxLength truncates error messages longer than n characters, marking truncation with "...". Zero (default) means
+// no limit.
+func WithMaxLength(n int) PrintOption {
+	return func(o *printOptions) {
+		o.maxLength = n
+	}
+}
+
+// WithColor makes PrettyPrint color its output with ANSI escape codes: error messages red, headers bold and
+// labels, summaries and stacks gray. Use it only when printing to terminals.
+func WithColor() PrintOption {
+	return func(o *printOptions) {
+		o.color = true
+	}
+}
+
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGray   = "\033[90m"
+	glyphBranch = "├── "
+	glyphLast   = "└── "
+	glyphPipe   = "│   "
+	glyphSpace  = "    "
+)
+
+// PrettyPrint prints the same information as multiError.Error() method but with newlines and indentation targeted
+// for humans. Labels attached with Annotate are printed after each error, e.g "connection refused [shard=3]".
+// By default nesting is shown with tab indentation and all errors are printed in full; use PrintOption to change it.
+func PrettyPrint(w io.Writer, err Error, opts ...PrintOption) error {
+	p := &printer{w: w}
+	for _, opt := range opts {
+		opt(&p.o)
+	}
+
+	errs := err.Errors()
+	if len(errs) > 1 {
+		if err := p.line("", p.header(len(errs))); err != nil {
+			return err
+		}
+	}
+	return p.printList(errs, 1, "")
+}
+
+type printer struct {
+	w       io.Writer
+	o       printOptions
+	written bool
+}
+
+// printList prints errors on the given depth. In tree glyphs mode prefix is the tree drawn for parent levels.
+func (p *printer) printList(errs []error, depth int, prefix string) error {
+	shown := len(errs)
+	if p.o.maxChildren > 0 && shown > p.o.maxChildren {
+		shown = p.o.maxChildren
+	}
+	for i := 0; i < shown; i++ {
+		linePrefix, childPrefix := p.prefixes(prefix, depth, i == len(errs)-1)
+		if err := p.printError(errs[i], depth, linePrefix, childPrefix); err != nil {
+			return err
+		}
+	}
+	if shown == len(errs) {
+		return nil
+	}
+	linePrefix, _ := p.prefixes(prefix, depth, true)
+	return p.line(linePrefix, p.colored(colorGray, "and "+strconv.Itoa(len(errs)-shown)+" more"))
+}
+
+// prefixes returns prefix of the line with error on the given depth and prefix of lines nested below it.
+func (p *printer) prefixes(prefix string, depth int, last bool) (linePrefix, childPrefix string) {
+	if !p.o.glyphs {
+		indent := strings.Repeat("\t", depth)
+		return indent, indent
+	}
+	if last {
+		return prefix + glyphLast, prefix + glyphSpace
+	}
+	return prefix + glyphBranch, prefix + glyphPipe
+}
+
+func (p *printer) printError(err error, depth int, linePrefix, childPrefix string) error {
+	if merr, ok := AsMulti(err); ok && (p.o.maxDepth <= 0 || depth < p.o.maxDepth) {
+		errs := merr.Errors()
+		if len(errs) > 1 {
+			// Header takes place of the error on the parent level.
+			if err := p.line(linePrefix, p.header(len(errs))); err != nil {
+				return err
+			}
+			return p.printList(errs, depth+1, childPrefix)
+		}
+		if p.o.glyphs {
+			return p.printError(errs[0], depth, linePrefix, childPrefix)
+		}
+		return p.printList(errs, depth+1, childPrefix)
+	}
+
+	text := p.colored(colorRed, p.truncate(err.Error()))
+	if labels := formatLabels(Labels(err)); labels != "" {
+		text += " " + p.colored(colorGray, labels[1:])
+	}
+	if err := p.line(linePrefix, text); err != nil {
+		return err
+	}
+	if !p.o.stacks {
+		return nil
+	}
+
+	stackPrefix := childPrefix
+	if !p.o.glyphs {
+		stackPrefix += "\t"
+	}
+	for _, f := range Stack(err) {
+		if err := p.line(stackPrefix, p.colored(colorGray, f.Function)); err != nil {
+			return err
+		}
+		if err := p.line(stackPrefix+"\t", p.colored(colorGray, f.File+":"+strconv.Itoa(f.Line))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// line writes single line. Lines are separated by new line, without trailing one.
+func (p *printer) line(prefix, text string) error {
+	if p.written {
+		prefix = "\n" + prefix
+	}
+	p.written = true
+	_, err := io.WriteString(p.w, prefix+text)
+	return err
+}
+
+func (p *printer) header(n int) string {
+	return p.colored(colorBold, strconv.Itoa(n)+" errors:")
+}
+
+func (p *printer) truncate(s string) string {
+	if p.o.maxLength <= 0 || utf8.RuneCountInString(s) <= p.o.maxLength {
+		return s
+	}
+	return string([]rune(s)[:p.o.maxLength]) + "..."
+}
+
+func (p *printer) colored(color, s string) string {
+	if !p.o.color {
+		return s
+	}
+	return color + s + colorReset
+}
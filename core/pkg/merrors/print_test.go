@@ -0,0 +1,93 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	"bytes"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func testPrintErr() merrors.Error {
+	return merrors.New(
+		stderrors.New("a"),
+		fmt.Errorf("wrap: %w", merrors.New(
+			stderrors.New("b"),
+			fmt.Errorf("wrap: %w", merrors.New(stderrors.New("c"), stderrors.New("d")).Err()),
+		).Err()),
+		merrors.Annotate(stderrors.New("connection refused"), "shard", "3"),
+	).Err()
+}
+
+func TestPrettyPrint_Options(t *testing.T) {
+	for _, tcase := range []struct {
+		name     string
+		opts     []merrors.PrintOption
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: "3 errors:\n\ta\n\t2 errors:\n\t\tb\n\t\t2 errors:\n\t\t\tc\n\t\t\td\n\tconnection refused [shard=3]",
+		},
+		{
+			name:     "tree glyphs",
+			opts:     []merrors.PrintOption{merrors.WithTreeGlyphs()},
+			expected: "3 errors:\n├── a\n├── 2 errors:\n│   ├── b\n│   └── 2 errors:\n│       ├── c\n│       └── d\n└── connection refused [shard=3]",
+		},
+		{
+			name:     "max depth",
+			opts:     []merrors.PrintOption{merrors.WithMaxDepth(2)},
+			expected: "3 errors:\n\ta\n\t2 errors:\n\t\tb\n\t\twrap: 2 errors: c; d\n\tconnection refused [shard=3]",
+		},
+		{
+			name:     "max children",
+			opts:     []merrors.PrintOption{merrors.WithTreeGlyphs(), merrors.WithMaxChildren(1)},
+			expected: "3 errors:\n├── a\n└── and 2 more",
+		},
+		{
+			name:     "max length",
+			opts:     []merrors.PrintOption{merrors.WithMaxDepth(1), merrors.WithMaxLength(10)},
+			expected: "3 errors:\n\ta\n\twrap: 2 er...\n\tconnection... [shard=3]",
+		},
+		{
+			name:     "color",
+			opts:     []merrors.PrintOption{merrors.WithMaxDepth(1), merrors.WithMaxChildren(1), merrors.WithColor()},
+			expected: "\033[1m3 errors:\033[0m\n\t\033[31ma\033[0m\n\t\033[90mand 2 more\033[0m",
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			testutil.Ok(t, merrors.PrettyPrint(b, testPrintErr(), tcase.opts...))
+			testutil.Equals(t, tcase.expected, b.String())
+		})
+	}
+}
+
+type failingWriter struct {
+	n   int
+	err error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, w.err
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestPrettyPrint_WriterError(t *testing.T) {
+	errWrite := stderrors.New("write failed")
+
+	// Each line is written separately, so fail on every nested one.
+	for n := 0; n < 8; n++ {
+		w := &failingWriter{n: n, err: errWrite}
+		testutil.Equals(t, errWrite, merrors.PrettyPrint(w, testPrintErr()), "n=%d", n)
+	}
+	testutil.Ok(t, merrors.PrettyPrint(&failingWriter{n: 8, err: errWrite}, testPrintErr()))
+}
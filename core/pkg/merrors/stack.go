@@ -0,0 +1,92 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors
+
+import (
+	stderrors "errors"
+	"runtime"
+)
+
+const maxStackDepth = 32
+
+// NewWithStacks returns NilOrMultiError with provided errors added if not nil, which records the stack of the caller for
+// each error added by NewWithStacks and Add. It's useful to tell where errors were added, e.g by which of many goroutines.
+// Recorded stacks can be accessed with Stack and printed with PrettyPrint using WithStacks option.
+func NewWithStacks(errs ...error) *NilOrMultiError {
+	m := &NilOrMultiError{stacks: true}
+	m.add(callers(), errs)
+	return m
+}
+
+// stackError is an error with the stack recorded when it was added to NilOrMultiError.
+// NOTE: Errors added with stacks are wrapped, so errors.As (not type assertion) must be used to check their type.
+type stackError struct {
+	err   error
+	stack []uintptr
+}
+
+func (e stackError) Error() string {
+	return e.err.Error()
+}
+
+func (e stackError) Unwrap() error {
+	return e.err
+}
+
+// withStack returns err with given stack attached, unless stack is nil or err has stack attached already.
+func withStack(err error, stack []uintptr) error {
+	if stack == nil {
+		return err
+	}
+	var s stackError
+	if stderrors.As(err, &s) {
+		return err
+	}
+	return stackError{err: err, stack: stack}
+}
+
+// callers returns stack of the caller of the function calling callers.
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	// Skip runtime.Callers, callers and its caller (e.g Add).
+	n := runtime.Callers(3, pcs)
+	return pcs[:n]
+}
+
+// Stack returns frames of the stack recorded when err was added to NilOrMultiError created with NewWithStacks,
+// starting from the caller of Add. It returns nil if no stack was recorded. Use Stacks to get stacks of all errors of
+// a multi error.
+func Stack(err error) []runtime.Frame {
+	for ; err != nil; err = stderrors.Unwrap(err) {
+		s, ok := err.(stackError)
+		if !ok {
+			continue
+		}
+		var frames []runtime.Frame
+		iter := runtime.CallersFrames(s.stack)
+		for {
+			f, more := iter.Next()
+			frames = append(frames, f)
+			if !more {
+				break
+			}
+		}
+		return frames
+	}
+	return nil
+}
+
+// Stacks returns stacks recorded for each of merr.Errors(), in the same order, if they were added to NilOrMultiError
+// created with NewWithStacks. Stack is nil for errors added without it. It returns nil if merr is nil.
+// NOTE: Errors added with stacks are wrapped, so use errors.As instead of type assertion to check their type.
+func Stacks(merr Error) [][]runtime.Frame {
+	if merr == nil {
+		return nil
+	}
+	stacks := make([][]runtime.Frame, 0, len(merr.Errors()))
+	for _, err := range merr.Errors() {
+		stacks = append(stacks, Stack(err))
+	}
+	return stacks
+}
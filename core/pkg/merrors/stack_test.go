@@ -0,0 +1,80 @@
+// Copyright (c) The EfficientGo Authors.
+// Licensed under the Apache License 2.0.
+
+package merrors_test
+
+import (
+	"bytes"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/efficientgo/tools/core/pkg/merrors"
+	"github.com/efficientgo/tools/core/pkg/testutil"
+)
+
+func addFailure(merr *merrors.NilOrMultiError, err error) {
+	merr.Add(err)
+}
+
+func TestNewWithStacks(t *testing.T) {
+	errA, errB := stderrors.New("a"), stderrors.New("b")
+
+	merr := merrors.NewWithStacks(errA)
+	addFailure(merr, errB)
+	// Errors from other multi errors keep stacks recorded there.
+	merr.Add(merrors.NewWithStacks(errA).Err())
+	// Errors without stacks do not get stacks from merging.
+	merrors.New(errA).Add(merr.Err())
+
+	err := merr.Err()
+	testutil.Equals(t, "3 errors: a; b; a", err.Error())
+	testutil.Assert(t, stderrors.Is(err, errB))
+	testutil.Equals(t, 2, err.Count(errA))
+
+	testutil.Equals(t, "github.com/efficientgo/tools/core/pkg/merrors_test.TestNewWithStacks", merrors.Stack(err.Errors()[0])[0].Function)
+	testutil.Equals(t, "github.com/efficientgo/tools/core/pkg/merrors_test.addFailure", merrors.Stack(err.Errors()[1])[0].Function)
+	testutil.Equals(t, "github.com/efficientgo/tools/core/pkg/merrors_test.TestNewWithStacks", merrors.Stack(err.Errors()[1])[1].Function)
+	testutil.Equals(t, "github.com/efficientgo/tools/core/pkg/merrors_test.TestNewWithStacks", merrors.Stack(err.Errors()[2])[0].Function)
+
+	testutil.Assert(t, merrors.Stack(errA) == nil)
+	testutil.Assert(t, merrors.Stack(merrors.New(errA).Err().Errors()[0]) == nil)
+
+	stacks := merrors.Stacks(err)
+	testutil.Equals(t, 3, len(stacks))
+	testutil.Equals(t, "github.com/efficientgo/tools/core/pkg/merrors_test.addFailure", stacks[1][0].Function)
+	testutil.Assert(t, merrors.Stacks(merrors.New(errA).Err())[0] == nil)
+	testutil.Assert(t, merrors.Stacks(nil) == nil)
+
+	// Stacks are kept when errors are annotated.
+	testutil.Equals(t, "github.com/efficientgo/tools/core/pkg/merrors_test.addFailure", merrors.Stack(merrors.Annotate(err.Errors()[1], "k", "v"))[0].Function)
+}
+
+func TestPrettyPrint_WithStacks(t *testing.T) {
+	merr := merrors.NewWithStacks()
+	addFailure(merr, stderrors.New("a"))
+
+	b := &bytes.Buffer{}
+	testutil.Ok(t, merrors.PrettyPrint(b, merr.Err()))
+	testutil.Equals(t, "\ta", b.String())
+
+	b.Reset()
+	testutil.Ok(t, merrors.PrettyPrint(b, merr.Err(), merrors.WithStacks()))
+	lines := strings.Split(b.String(), "\n")
+	testutil.Equals(t, "\ta", lines[0])
+	testutil.Equals(t, "\t\tgithub.com/efficientgo/tools/core/pkg/merrors_test.addFailure", lines[1])
+	testutil.Assert(t, strings.HasPrefix(lines[2], "\t\t\t") && strings.Contains(lines[2], "stack_test.go:"), lines[2])
+	testutil.Equals(t, "\t\tgithub.com/efficientgo/tools/core/pkg/merrors_test.TestPrettyPrint_WithStacks", lines[3])
+}
+
+func TestNewWithStacks_Dedup(t *testing.T) {
+	errA := stderrors.New("a")
+
+	// Deduplicated errors re-added with stacks keep their occurrences.
+	merr := merrors.NewWithStacks(merrors.Dedup(merrors.New(errA, errA, errA).Err()))
+	testutil.Equals(t, 3, merrors.Occurrences(merr.Err().Errors()[0]))
+	testutil.Equals(t, 3, merr.Err().Count(errA))
+
+	merr.Add(errA)
+	testutil.Equals(t, "a (x4)", merrors.Dedup(merr.Err()).Error())
+}